@@ -29,7 +29,7 @@ func (l *ActionWander) Tick() aitree.State {
 	// Check if we are already following a path.
 	// If not, we select a random point within 128 meters.
 	if !l.ai.CAiPath.active {
-		l.ai.SetTarget(vectors.RandomVec2(128.0))
+		l.ai.SetTarget(l.ai.w.randomVec2(128.0))
 	}
 
 	return aitree.StateRunning
@@ -3,7 +3,6 @@ package gamecs
 import (
 	"fmt"
 	"log"
-	"sort"
 
 	"github.com/Flokey82/go_gens/vectors"
 )
@@ -16,6 +15,7 @@ type CAiPerception struct {
 	Entities []*Agent
 	Items    []*Item
 	maxDist  float64
+	pos      vectors.Vec2 // Position at the last perception update.
 }
 
 func newCAiPerception() *CAiPerception {
@@ -52,32 +52,41 @@ func (c *CAiPerception) CanSee(it *Item) bool {
 	return false
 }
 
+// NearestItem returns the closest item with the given tag within the
+// perception distance (if any).
+func (c *CAiPerception) NearestItem(tag string) *Item {
+	items := c.w.mgr.NearestItems(c.pos, 1, c.maxDist, func(it *Item) bool {
+		return it.HasTag(tag)
+	})
+	if len(items) == 0 {
+		return nil
+	}
+	return items[0]
+}
+
+// NearestEntity returns the closest agent within the perception distance
+// that passes the (optional) filter.
+func (c *CAiPerception) NearestEntity(filter func(e *Agent) bool) *Agent {
+	entities := c.w.mgr.NearestEntities(c.pos, 1, c.maxDist, func(e *Agent) bool {
+		return e.id != c.ai.id && (filter == nil || filter(e))
+	})
+	if len(entities) == 0 {
+		return nil
+	}
+	return entities[0]
+}
+
 // Update updates the list of visible items / entities.
 func (c *CAiPerception) Update(m *CompMovable, delta float64) {
 	// TODO: Send notifications on newly spotted entities and items and those we lost sight of.
+	c.pos = m.Pos
 
-	// Update perceived agents.
-	// TODO: Deduplicate with percieved items.
-	c.Entities = nil
-	for _, ce := range c.w.mgr.Entities() {
-		if ce.CompMovable != m && vectors.Dist2(ce.CompMovable.Pos, m.Pos) < c.maxDist {
-			c.Entities = append(c.Entities, ce)
-		}
-	}
-	sort.Slice(c.Entities, func(i, j int) bool {
-		return vectors.Dist2(c.Entities[i].Pos, m.Pos) < vectors.Dist2(c.Entities[j].Pos, m.Pos)
+	// Update perceived agents (sorted by distance).
+	c.Entities = c.w.mgr.EntitiesInRadius(m.Pos, c.maxDist, func(e *Agent) bool {
+		return e.id != c.ai.id
 	})
 
-	// Update perceived items.
-	// TODO: Deduplicate with percieved agents.
-	c.Items = nil
-	for _, it := range c.w.mgr.Items() {
-		if it.Location == LocWorld && vectors.Dist2(it.Pos, m.Pos) < c.maxDist {
-			c.Items = append(c.Items, it)
-		}
-	}
-	sort.Slice(c.Items, func(i, j int) bool {
-		return vectors.Dist2(c.Items[i].Pos, m.Pos) < vectors.Dist2(c.Items[j].Pos, m.Pos)
-	})
+	// Update perceived items (sorted by distance).
+	c.Items = c.w.mgr.ItemsInRadius(m.Pos, c.maxDist, nil)
 	log.Println(fmt.Sprintf("saw %d Entities, %d Items", len(c.Entities), len(c.Items)))
 }
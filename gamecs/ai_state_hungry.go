@@ -37,10 +37,10 @@ func NewStateFindFood(ai *CompAi) *StateFindFood {
 		if s.foundItem() {
 			return true // We have already found an item, so stop wandering.
 		}
-		// We can see an item, so stop wandering and set the item
-		// as our target.
-		if len(s.ai.CAiPerception.Items) > 0 {
-			s.it = s.ai.CAiPerception.Items[0]
+		// We can see some food, so stop wandering and set the
+		// closest food item as our target.
+		if it := s.ai.NearestItem("food"); it != nil {
+			s.it = it
 			return true
 		}
 
@@ -74,6 +74,19 @@ func (s *StateFindFood) foundItem() bool {
 	return s.it != nil && s.ai.CanSee(s.it)
 }
 
+// refID returns the ID of the item we are currently after (if any).
+func (s *StateFindFood) refID() (int, bool) {
+	if s.it == nil {
+		return 0, false
+	}
+	return s.it.id, true
+}
+
+// restoreRef restores the item we are after from the given ID.
+func (s *StateFindFood) restoreRef(id int) {
+	s.it = s.ai.w.mgr.itemsByID[id]
+}
+
 func (s *StateFindFood) Type() aistate.StateType {
 	return StateTypeFind
 }
@@ -37,7 +37,7 @@ func (s *StateFlee) OnEnter() {
 	s.ai.CAiPath.running = true // Run away!
 	// Select a random point to run towards.
 	// Ideally we'd choose target location that would lead us away from the threat.
-	s.ai.SetTarget(vectors.RandomVec2(128.0))
+	s.ai.SetTarget(s.ai.w.randomVec2(128.0))
 	log.Println(fmt.Sprintf("fleeing to Target %.2f, %.2f", s.ai.CAiPath.Target.X, s.ai.CAiPath.Target.Y))
 }
 
@@ -102,18 +102,13 @@ func (s *StateAttack) findTarget() {
 		return
 	}
 
-	// Set our target to the current position of the first entity that we have perceived.
-	// (the first is the closest).
+	// Set our target to the closest living entity that we can perceive.
 	// Ideally we would choose our target based on distance, threat level, etc.
-	if len(s.ai.Entities) > 0 {
-		for _, e := range s.ai.Entities {
-			if e.Dead() {
-				continue
-			}
-			s.target = e
-			s.ai.running = true // Run to intercept.
-			break
-		}
+	if e := s.ai.NearestEntity(func(e *Agent) bool {
+		return !e.Dead()
+	}); e != nil {
+		s.target = e
+		s.ai.running = true // Run to intercept.
 	} else {
 		s.giveUpTarget() // No target in sight, give up.
 	}
@@ -126,6 +121,19 @@ func (s *StateAttack) giveUpTarget() {
 	s.ai.running = false // No need to run anymore.
 }
 
+// refID returns the ID of the agent we are currently attacking (if any).
+func (s *StateAttack) refID() (int, bool) {
+	if s.target == nil {
+		return 0, false
+	}
+	return s.target.id, true
+}
+
+// restoreRef restores the agent we are attacking from the given ID.
+func (s *StateAttack) restoreRef(id int) {
+	s.target = s.ai.w.mgr.GetEntityFromID(id)
+}
+
 func (s *StateAttack) Tick(delta uint64) {
 	if s.ait.Tick() == aitree.StateFailure {
 		log.Println(fmt.Sprintf("%d: StateAttack failed!!", s.ai.id))
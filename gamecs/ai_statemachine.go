@@ -6,14 +6,27 @@ import (
 
 type CAiScheduler struct {
 	*aistate.StateMachine
+	states map[aistate.StateType]aistate.State // All states known to the scheduler.
 }
 
 func newCAiScheduler() *CAiScheduler {
 	return &CAiScheduler{
 		StateMachine: aistate.New(),
+		states:       make(map[aistate.StateType]aistate.State),
 	}
 }
 
+// addState registers the given state with the scheduler, so it can be
+// looked up by its type (e.g. when restoring a snapshot).
+func (c *CAiScheduler) addState(s aistate.State) {
+	c.states[s.Type()] = s
+}
+
+// getState returns the registered state of the given type (if any).
+func (c *CAiScheduler) getState(t aistate.StateType) aistate.State {
+	return c.states[t]
+}
+
 // init initializes the state machine that controls agent behavior.
 //
 // NOTE: This is super-messy and would need to be re-written from
@@ -25,6 +38,8 @@ func (c *CAiScheduler) init(ai *CompAi) {
 	// Set up the two states we decide on if we are being threatened.
 	sFlee := NewStateFlee(ai)     // Flee from predator
 	sAttack := NewStateAttack(ai) // Attack
+	c.addState(sFlee)
+	c.addState(sAttack)
 
 	// Allow the transition to return one of multiple different transitions.
 	c.AddAnySelector(func() aistate.State {
@@ -38,6 +53,7 @@ func (c *CAiScheduler) init(ai *CompAi) {
 
 	// Empty our inventory if it is full.
 	sStore := NewStateStoreFood(ai)
+	c.addState(sStore)
 	c.AddAnyTransition(sStore, func() bool {
 		return ai.w.mgr.GetEntityFromID(ai.id).CompInventory.IsFull()
 	})
@@ -45,6 +61,8 @@ func (c *CAiScheduler) init(ai *CompAi) {
 	// If we get hungry....
 	sFind := NewStateFindFood(ai) // Find food
 	sMunch := NewStateEatFood(ai) // Eat food
+	c.addState(sFind)
+	c.addState(sMunch)
 	c.AddAnySelector(func() aistate.State {
 		if ai.CAiStatus.HasFood() {
 			return sMunch // If we have food, we can go munch.
@@ -54,6 +72,7 @@ func (c *CAiScheduler) init(ai *CompAi) {
 
 	// If we get sleepy, get some rest.
 	sRest := NewStateRest(ai)
+	c.addState(sRest)
 	c.AddAnyTransition(sRest, ai.CAiStatus.IsFunc(sExhausted))
 
 	// Make sure we always have some food in our pocket.
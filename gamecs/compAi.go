@@ -1,8 +1,6 @@
 package gamecs
 
 import (
-	"math/rand"
-
 	"github.com/Flokey82/aifiver"
 )
 
@@ -31,7 +29,7 @@ func newCompAi(w *World, id int) *CompAi {
 		CAiPath:       newCAiPath(),
 	}
 	// Randomize.
-	c.SmallModel[aifiver.FactorAgreeableness] = rand.Intn(10) - 5
+	c.SmallModel[aifiver.FactorAgreeableness] = w.rng.Intn(10) - 5
 
 	c.CAiPerception.init(c)
 	c.CAiScheduler.init(c)
@@ -33,6 +33,7 @@ func (in *CompInventory) Add(it *Item) bool {
 	it.Location = LocInventory
 	it.LocationID = in.id
 	in.Slots = append(in.Slots, it)
+	in.w.mgr.updateItem(it)
 	return true
 }
 
@@ -70,6 +71,7 @@ func (in *CompInventory) Drop(it *Item) bool {
 		it.Location = LocWorld
 		it.LocationID = -1
 		it.Pos = in.w.mgr.GetEntityFromID(in.id).Pos
+		in.w.mgr.updateItem(it)
 		return true
 	}
 	return false
@@ -2,15 +2,10 @@ package gamecs
 
 import (
 	"log"
-	"math/rand"
 
 	"github.com/Flokey82/go_gens/gamesheet"
 )
 
-func randByte() byte {
-	return byte(rand.Intn(255))
-}
-
 // CompStatus is a component that handles the status or state
 // of an entity (Health, stamina, hunger, thirst...).
 type CompStatus struct {
@@ -19,9 +14,9 @@ type CompStatus struct {
 }
 
 // newCompStatus returns a new status component.
-func newCompStatus() *CompStatus {
+func newCompStatus(w *World) *CompStatus {
 	return &CompStatus{
-		cs: gamesheet.New(100, 100, 0, randByte(), randByte(), randByte(), randByte()),
+		cs: gamesheet.New(100, 100, 0, w.randByte(), w.randByte(), w.randByte(), w.randByte()),
 	}
 }
 
@@ -3,7 +3,6 @@ package gamecs
 import (
 	"fmt"
 	"log"
-	"math/rand"
 
 	"github.com/Flokey82/go_gens/vectors"
 )
@@ -24,8 +23,8 @@ func (w *World) NewChar() *Agent {
 	c := newAgent(w)
 	w.mgr.RegisterEntity(c)
 	l := newLocation(w, w.mgr.NextID(), vectors.NewVec2(
-		float64(rand.Intn(w.Height/2)),
-		float64(rand.Intn(w.Width/2)),
+		float64(w.rng.Intn(w.Height/2)),
+		float64(w.rng.Intn(w.Width/2)),
 	))
 	w.mgr.RegisterLocation(l)
 	c.SetLocation("home", l)
@@ -38,10 +37,10 @@ func newAgent(w *World) *Agent {
 	a := &Agent{
 		id: id,
 		CompMovable: newCompMovable(vectors.NewVec2(
-			float64(rand.Intn(w.Height)),
-			float64(rand.Intn(w.Width)),
+			float64(w.rng.Intn(w.Height)),
+			float64(w.rng.Intn(w.Width)),
 		)),
-		CompStatus:    newCompStatus(),
+		CompStatus:    newCompStatus(w),
 		CompInventory: newCompInventory(w, id, 3),
 		CompAi:        newCompAi(w, id),
 		w:             w,
@@ -55,6 +54,7 @@ func newAgent(w *World) *Agent {
 // that allows the extension of the AI.
 func (c *Agent) SetProfession(w *World, p *ProfessionType) {
 	c.Profession = p.New(w, c, c.GetLocation("home"))
+	c.CompAi.CAiScheduler.addState(c.Profession)
 
 	// We currently only work if we don't have any other worries.
 	c.CompAi.CAiScheduler.AddAnyTransition(c.Profession, c.CompAi.Idle)
@@ -69,6 +69,7 @@ func (c *Agent) ID() int {
 func (c *Agent) Update(delta float64) {
 	c.CompAi.Update(c.CompMovable, c.CompStatus, delta)
 	c.CompMovable.Update(delta)
+	c.w.mgr.updateEntity(c)
 	c.CompStatus.Update(delta)
 }
 
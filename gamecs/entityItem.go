@@ -45,6 +45,16 @@ func NewItemType(name string, tags ...string) *ItemType {
 	}
 }
 
+// HasTag returns true if the item type has the given tag.
+func (i *ItemType) HasTag(tag string) bool {
+	for _, t := range i.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // New returns a new item of the current type.
 func (i *ItemType) New(w *World, pos vectors.Vec2) *Item {
 	return &Item{
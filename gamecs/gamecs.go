@@ -8,7 +8,9 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"time"
 
+	"github.com/Flokey82/go_gens/utils"
 	"github.com/Flokey82/go_gens/vectors"
 )
 
@@ -19,9 +21,26 @@ type World struct {
 	Width   int
 	Height  int
 	mgr     *Manager
+	seed    int64                 // Seed of the world RNG.
+	rng     *rand.Rand            // Random number generator used by the simulation.
+	src     *utils.XorshiftSource // Source of rng, exposes the RNG state.
 }
 
+// New returns a new world seeded with the current time.
 func New() *World {
+	return NewWithSeed(time.Now().UnixNano())
+}
+
+// NewWithSeed returns a new world using the given seed for all
+// random decisions made by the simulation.
+func NewWithSeed(seed int64) *World {
+	w := newWorld(seed)
+	w.placeFood()
+	return w
+}
+
+// newWorld returns a new, empty world with the given seed.
+func newWorld(seed int64) *World {
 	w := &World{
 		palette: []color.Color{
 			color.RGBA{0x00, 0x00, 0x00, 0xff}, color.RGBA{0x00, 0x00, 0xff, 0xff},
@@ -31,18 +50,25 @@ func New() *World {
 		},
 		Width:  128,
 		Height: 128,
+		seed:   seed,
 	}
+	w.src = utils.NewXorshiftSource(seed)
+	w.rng = rand.New(w.src)
 	w.mgr = newManager()
-	w.placeFood()
 	return w
 }
 
+// randomVec2 returns a randomized vector using the world RNG.
+func (w *World) randomVec2(scale float64) vectors.Vec2 {
+	return vectors.NewVec2(scale*w.rng.Float64(), scale*w.rng.Float64())
+}
+
 func (w *World) placeFood() {
 	itFood := NewItemType("goulash", "food")
 	for i := 0; i < 200; i++ {
 		w.mgr.RegisterItem(itFood.New(w, vectors.Vec2{
-			X: float64(rand.Intn(w.Height)),
-			Y: float64(rand.Intn(w.Width)),
+			X: float64(w.rng.Intn(w.Height)),
+			Y: float64(w.rng.Intn(w.Width)),
 		}))
 	}
 }
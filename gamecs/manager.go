@@ -1,6 +1,10 @@
 package gamecs
 
-import "log"
+import (
+	"log"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
 
 // Manager is a rudimentary entity manager.
 // NOTE: This sucks. I really need to re-think and
@@ -13,6 +17,8 @@ type Manager struct {
 	locationsByID map[int]*Location
 	locations     []*Location
 	nextID        int
+	entityIndex   *spatialHash // Spatial index of all entities.
+	itemIndex     *spatialHash // Spatial index of all items in the world.
 }
 
 // newManager returns a new entity manager.
@@ -21,6 +27,8 @@ func newManager() *Manager {
 		entitiesByID:  make(map[int]*Agent),
 		itemsByID:     make(map[int]*Item),
 		locationsByID: make(map[int]*Location),
+		entityIndex:   newSpatialHash(spatialCellSize),
+		itemIndex:     newSpatialHash(spatialCellSize),
 	}
 }
 
@@ -39,6 +47,8 @@ func (m *Manager) Reset() {
 	m.items = nil
 	m.locationsByID = make(map[int]*Location)
 	m.locations = nil
+	m.entityIndex = newSpatialHash(spatialCellSize)
+	m.itemIndex = newSpatialHash(spatialCellSize)
 }
 
 // Locations returns all registered locations.
@@ -61,11 +71,58 @@ func (m *Manager) Items() []*Item {
 func (m *Manager) RegisterItem(it *Item) {
 	m.itemsByID[it.ID()] = it
 	m.items = append(m.items, it)
+	m.updateItem(it)
+}
+
+// updateItem updates the spatial index for the given item, which needs to
+// be called whenever the item is moved, picked up or dropped.
+func (m *Manager) updateItem(it *Item) {
+	if m.itemsByID[it.id] != it || it.Location != LocWorld {
+		m.itemIndex.remove(it.id)
+		return
+	}
+	m.itemIndex.insert(it.id, it.Pos)
+}
+
+// ItemsInRadius returns all items in the world closer than r to the given
+// position that pass the (optional) filter, sorted by distance.
+func (m *Manager) ItemsInRadius(pos vectors.Vec2, r float64, filter func(it *Item) bool) []*Item {
+	return m.itemsFromIDs(m.itemIndex.inRadius(pos, r, m.itemFilter(filter)))
+}
+
+// NearestItems returns up to k items in the world closer than maxDist to
+// the given position that pass the (optional) filter, sorted by distance.
+func (m *Manager) NearestItems(pos vectors.Vec2, k int, maxDist float64, filter func(it *Item) bool) []*Item {
+	return m.itemsFromIDs(m.itemIndex.nearest(pos, k, maxDist, m.itemFilter(filter)))
+}
+
+// itemFilter wraps the given item filter so it can be used with the
+// spatial index.
+func (m *Manager) itemFilter(filter func(it *Item) bool) func(id int) bool {
+	if filter == nil {
+		return nil
+	}
+	return func(id int) bool {
+		return filter(m.itemsByID[id])
+	}
+}
+
+// itemsFromIDs returns the items with the given IDs.
+func (m *Manager) itemsFromIDs(ids []int) []*Item {
+	if len(ids) == 0 {
+		return nil
+	}
+	res := make([]*Item, len(ids))
+	for i, id := range ids {
+		res[i] = m.itemsByID[id]
+	}
+	return res
 }
 
 // RemoveItem removes the given item from the world.
 func (m *Manager) RemoveItem(it *Item) {
 	delete(m.itemsByID, it.ID())
+	m.itemIndex.remove(it.ID())
 	for i, in := range m.items {
 		if in == it {
 			m.items = append(m.items[:i], m.items[i+1:]...)
@@ -89,12 +146,58 @@ func (m *Manager) Entities() []*Agent {
 func (m *Manager) RegisterEntity(e *Agent) {
 	m.entitiesByID[e.ID()] = e
 	m.entities = append(m.entities, e)
+	m.entityIndex.insert(e.ID(), e.Pos)
+}
+
+// updateEntity updates the spatial index for the given agent, which needs
+// to be called whenever the position of the agent changes.
+func (m *Manager) updateEntity(e *Agent) {
+	if m.entitiesByID[e.ID()] != e {
+		return
+	}
+	m.entityIndex.insert(e.ID(), e.Pos)
+}
+
+// EntitiesInRadius returns all agents closer than r to the given position
+// that pass the (optional) filter, sorted by distance.
+func (m *Manager) EntitiesInRadius(pos vectors.Vec2, r float64, filter func(e *Agent) bool) []*Agent {
+	return m.entitiesFromIDs(m.entityIndex.inRadius(pos, r, m.entityFilter(filter)))
+}
+
+// NearestEntities returns up to k agents closer than maxDist to the given
+// position that pass the (optional) filter, sorted by distance.
+func (m *Manager) NearestEntities(pos vectors.Vec2, k int, maxDist float64, filter func(e *Agent) bool) []*Agent {
+	return m.entitiesFromIDs(m.entityIndex.nearest(pos, k, maxDist, m.entityFilter(filter)))
+}
+
+// entityFilter wraps the given agent filter so it can be used with the
+// spatial index.
+func (m *Manager) entityFilter(filter func(e *Agent) bool) func(id int) bool {
+	if filter == nil {
+		return nil
+	}
+	return func(id int) bool {
+		return filter(m.entitiesByID[id])
+	}
+}
+
+// entitiesFromIDs returns the agents with the given IDs.
+func (m *Manager) entitiesFromIDs(ids []int) []*Agent {
+	if len(ids) == 0 {
+		return nil
+	}
+	res := make([]*Agent, len(ids))
+	for i, id := range ids {
+		res[i] = m.entitiesByID[id]
+	}
+	return res
 }
 
 // RemoveEntity removes the given agent from the manager.
 // NOTE: This should be generic and not be typed as *Agent.
 func (m *Manager) RemoveEntity(e *Agent) {
 	delete(m.entitiesByID, e.ID())
+	m.entityIndex.remove(e.ID())
 	for i, en := range m.entities {
 		if en == e {
 			m.entities = append(m.entities[:i], m.entities[i+1:]...)
@@ -2,7 +2,6 @@ package gamecs
 
 import (
 	"log"
-	"math/rand"
 
 	"github.com/Flokey82/aistate"
 )
@@ -72,7 +71,7 @@ func (s *Profession) selectNewProject() {
 	// Do we have a current project?
 	if s.CurrentProject == nil {
 		// No: Select a new project
-		s.CurrentProject = newProject(s.CanCraft[rand.Intn(len(s.CanCraft))])
+		s.CurrentProject = newProject(s.CanCraft[s.w.rng.Intn(len(s.CanCraft))])
 	}
 
 	// Any missing resources?
@@ -0,0 +1,6 @@
+package gamecs
+
+// randByte returns a random byte using the world RNG.
+func (w *World) randByte() byte {
+	return byte(w.rng.Intn(255))
+}
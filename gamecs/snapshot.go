@@ -0,0 +1,527 @@
+package gamecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Flokey82/aifiver"
+	"github.com/Flokey82/aistate"
+	"github.com/Flokey82/go_gens/gamesheet"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// Snapshot is a serializable representation of the complete state of a
+// World, which allows us to checkpoint long running simulations and resume
+// them later on.
+//
+// Since entities, items and locations reference each other through
+// pointers, all references are stored as IDs and resolved on restore.
+// Item and profession types are stored only once and referenced by index.
+//
+// NOTE: The behavior trees used within the AI states are not persisted since
+// they don't carry any state between ticks (their actions only act on the
+// persisted components), see TestSnapshotContinuesDeterministically. The GIF
+// frames are not persisted.
+type Snapshot struct {
+	Width           int
+	Height          int
+	NextID          int                       // Next available unique identifier.
+	Seed            int64                     // Seed of the world RNG.
+	RandState       uint64                    // State of the world RNG.
+	ItemTypes       []*ItemTypeSnapshot       // All item types referenced by items and professions.
+	ProfessionTypes []*ProfessionTypeSnapshot // All profession types used by agents.
+	Items           []*ItemSnapshot           // All items (in the world or in inventories).
+	Locations       []*LocationSnapshot       // All registered locations.
+	Entities        []*AgentSnapshot          // All registered agents (in update order).
+}
+
+// ItemTypeSnapshot is the serializable representation of an ItemType.
+type ItemTypeSnapshot struct {
+	Name       string
+	Tags       []string
+	Properties map[string]int
+	Requires   []int // Indices of the required item types.
+}
+
+// ProfessionTypeSnapshot is the serializable representation of a ProfessionType.
+type ProfessionTypeSnapshot struct {
+	Name     string
+	CanCraft []int // Indices of the craftable item types.
+}
+
+// ItemSnapshot is the serializable representation of an Item.
+type ItemSnapshot struct {
+	ID         int
+	Type       int // Index of the item type.
+	Location   ItemLocation
+	LocationID int
+	Pos        vectors.Vec2
+	Registered bool // The item is registered with the manager.
+}
+
+// InventorySnapshot is the serializable representation of a CompInventory.
+type InventorySnapshot struct {
+	Size  int
+	Items []int // IDs of the items in the inventory.
+}
+
+// LocationSnapshot is the serializable representation of a Location.
+type LocationSnapshot struct {
+	ID        int
+	Pos       vectors.Vec2
+	Inventory InventorySnapshot
+}
+
+// AgentSnapshot is the serializable representation of an Agent.
+type AgentSnapshot struct {
+	ID         int
+	Pos        vectors.Vec2
+	Speed      vectors.Vec2
+	Sleeping   bool
+	Sheet      *gamesheet.CharacterSheet
+	SheetTicks uint16 // Milliseconds towards the next AP/HP regeneration.
+	Inventory  InventorySnapshot
+	AI         AiSnapshot
+	Profession *ProfessionSnapshot // Profession of the agent (if any).
+}
+
+// AiSnapshot is the serializable representation of a CompAi.
+type AiSnapshot struct {
+	Personality     aifiver.SmallModel
+	State           *aistate.StateType        // Current AI state (if any).
+	PreviousState   *aistate.StateType        // Previous AI state (if any).
+	StateRefs       map[aistate.StateType]int // IDs of items or entities referenced by AI states.
+	Status          map[string]bool           // Evaluated needs (hungry, threatened, ...).
+	Eat             bool
+	Locations       map[string]int          // Remembered locations (IDs) by tag.
+	Positions       map[string]vectors.Vec2 // Remembered positions by tag.
+	Waypoints       []vectors.Vec2
+	WaypointCurrent int
+	Target          vectors.Vec2
+	Active          bool
+	Running         bool
+	Planned         bool
+}
+
+// ProfessionSnapshot is the serializable representation of a Profession.
+type ProfessionSnapshot struct {
+	Type     int              // Index of the profession type.
+	Workshop int              // ID of the workshop location.
+	Project  *ProjectSnapshot // Current project (if any).
+	Missing  []int            // Indices of the missing item types.
+}
+
+// ProjectSnapshot is the serializable representation of a Project.
+type ProjectSnapshot struct {
+	Produce  int // Index of the item type to produce.
+	Progress uint64
+	Duration uint64
+	Complete bool
+}
+
+// stateReferrer is implemented by AI states that reference an item or
+// entity, which needs to be persisted in a snapshot.
+type stateReferrer interface {
+	refID() (int, bool) // returns the ID of the referenced item or entity (if any)
+	restoreRef(id int)  // restores the reference from the given ID
+}
+
+// Snapshot returns a serializable snapshot of the current world state.
+func (w *World) Snapshot() *Snapshot {
+	s := &Snapshot{
+		Width:     w.Width,
+		Height:    w.Height,
+		NextID:    w.mgr.nextID,
+		Seed:      w.seed,
+		RandState: w.src.State,
+	}
+
+	// Item types are shared between items, so we store them only once.
+	itemTypes := make(map[*ItemType]int)
+	var addItemType func(t *ItemType) int
+	addItemType = func(t *ItemType) int {
+		if idx, ok := itemTypes[t]; ok {
+			return idx
+		}
+		idx := len(s.ItemTypes)
+		itemTypes[t] = idx
+		ts := &ItemTypeSnapshot{
+			Name:       t.Name,
+			Tags:       t.Tags,
+			Properties: t.Properties,
+		}
+		s.ItemTypes = append(s.ItemTypes, ts)
+		for _, req := range t.Requires {
+			ts.Requires = append(ts.Requires, addItemType(req))
+		}
+		return idx
+	}
+
+	// Items might not be registered with the manager (e.g. produced
+	// items in a workshop), so we also collect all items in inventories.
+	seenItems := make(map[*Item]bool)
+	addItem := func(it *Item, registered bool) int {
+		if !seenItems[it] {
+			seenItems[it] = true
+			s.Items = append(s.Items, &ItemSnapshot{
+				ID:         it.id,
+				Type:       addItemType(it.ItemType),
+				Location:   it.Location,
+				LocationID: it.LocationID,
+				Pos:        it.Pos,
+				Registered: registered,
+			})
+		}
+		return it.id
+	}
+	for _, it := range w.mgr.items {
+		addItem(it, true)
+	}
+	snapshotInventory := func(in *CompInventory) InventorySnapshot {
+		is := InventorySnapshot{Size: in.Size}
+		for _, it := range in.Slots {
+			is.Items = append(is.Items, addItem(it, false))
+		}
+		return is
+	}
+
+	for _, loc := range w.mgr.locations {
+		s.Locations = append(s.Locations, &LocationSnapshot{
+			ID:        loc.id,
+			Pos:       loc.Pos,
+			Inventory: snapshotInventory(loc.CompInventory),
+		})
+	}
+
+	professionTypes := make(map[*ProfessionType]int)
+	for _, a := range w.mgr.entities {
+		as := &AgentSnapshot{
+			ID:         a.id,
+			Pos:        a.Pos,
+			Speed:      a.Speed,
+			Sleeping:   a.Sleeping,
+			Sheet:      a.cs,
+			SheetTicks: a.cs.TickCounter(),
+			Inventory:  snapshotInventory(a.CompInventory),
+			AI:         a.CompAi.snapshot(),
+		}
+		if p := a.Profession; p != nil {
+			idx, ok := professionTypes[p.ProfessionType]
+			if !ok {
+				idx = len(s.ProfessionTypes)
+				professionTypes[p.ProfessionType] = idx
+				pts := &ProfessionTypeSnapshot{Name: p.Name}
+				for _, t := range p.CanCraft {
+					pts.CanCraft = append(pts.CanCraft, addItemType(t))
+				}
+				s.ProfessionTypes = append(s.ProfessionTypes, pts)
+			}
+			ps := &ProfessionSnapshot{
+				Type:     idx,
+				Workshop: p.workshop.id,
+			}
+			if pr := p.CurrentProject; pr != nil {
+				ps.Project = &ProjectSnapshot{
+					Produce:  addItemType(pr.Produce),
+					Progress: pr.Progress,
+					Duration: pr.Duration,
+					Complete: pr.Complete,
+				}
+			}
+			for _, t := range p.Missing {
+				ps.Missing = append(ps.Missing, addItemType(t))
+			}
+			as.Profession = ps
+		}
+		s.Entities = append(s.Entities, as)
+	}
+	return s
+}
+
+// snapshot returns the serializable state of the AI component.
+func (c *CompAi) snapshot() AiSnapshot {
+	as := AiSnapshot{
+		Personality:     c.SmallModel,
+		StateRefs:       make(map[aistate.StateType]int),
+		Status:          make(map[string]bool),
+		Eat:             c.eat,
+		Locations:       make(map[string]int),
+		Positions:       make(map[string]vectors.Vec2),
+		Waypoints:       c.Waypoints,
+		WaypointCurrent: c.WaypointCurrent,
+		Target:          c.Target,
+		Active:          c.active,
+		Running:         c.running,
+		Planned:         c.planned,
+	}
+	if c.Current != nil {
+		st := c.Current.Type()
+		as.State = &st
+	}
+	if c.Previous != nil {
+		st := c.Previous.Type()
+		as.PreviousState = &st
+	}
+	for t, st := range c.CAiScheduler.states {
+		if r, ok := st.(stateReferrer); ok {
+			if id, ok := r.refID(); ok {
+				as.StateRefs[t] = id
+			}
+		}
+	}
+	for k, v := range c.CAiStatus.states {
+		as.Status[k] = v
+	}
+	for tag, loc := range c.CAiMemory.Locations {
+		as.Locations[tag] = loc.id
+	}
+	for tag, pos := range c.CAiMemory.Positions {
+		as.Positions[tag] = pos
+	}
+	return as
+}
+
+// NewFromSnapshot restores a world from the given snapshot.
+func NewFromSnapshot(s *Snapshot) (*World, error) {
+	w := newWorld(s.Seed)
+	w.Width = s.Width
+	w.Height = s.Height
+
+	// Restore the item types.
+	itemTypes := make([]*ItemType, len(s.ItemTypes))
+	for i, ts := range s.ItemTypes {
+		t := NewItemType(ts.Name, ts.Tags...)
+		for k, v := range ts.Properties {
+			t.Properties[k] = v
+		}
+		itemTypes[i] = t
+	}
+	getItemType := func(idx int) (*ItemType, error) {
+		if idx < 0 || idx >= len(itemTypes) {
+			return nil, fmt.Errorf("invalid item type index %d", idx)
+		}
+		return itemTypes[idx], nil
+	}
+	for i, ts := range s.ItemTypes {
+		for _, idx := range ts.Requires {
+			req, err := getItemType(idx)
+			if err != nil {
+				return nil, err
+			}
+			itemTypes[i].Requires = append(itemTypes[i].Requires, req)
+		}
+	}
+
+	// Restore the profession types.
+	professionTypes := make([]*ProfessionType, len(s.ProfessionTypes))
+	for i, ps := range s.ProfessionTypes {
+		pt := NewProfessionType(ps.Name)
+		for _, idx := range ps.CanCraft {
+			t, err := getItemType(idx)
+			if err != nil {
+				return nil, err
+			}
+			pt.CanCraft = append(pt.CanCraft, t)
+		}
+		professionTypes[i] = pt
+	}
+
+	// Restore all items.
+	items := make(map[int]*Item)
+	for _, is := range s.Items {
+		t, err := getItemType(is.Type)
+		if err != nil {
+			return nil, err
+		}
+		it := &Item{
+			id:         is.ID,
+			Location:   is.Location,
+			LocationID: is.LocationID,
+			Pos:        is.Pos,
+			ItemType:   t,
+		}
+		items[is.ID] = it
+		if is.Registered {
+			w.mgr.RegisterItem(it)
+		}
+	}
+	restoreInventory := func(in *CompInventory, is InventorySnapshot) error {
+		in.Size = is.Size
+		for _, id := range is.Items {
+			it, ok := items[id]
+			if !ok {
+				return fmt.Errorf("unknown item %d in inventory %d", id, in.id)
+			}
+			in.Slots = append(in.Slots, it)
+		}
+		return nil
+	}
+
+	// Restore all locations.
+	for _, ls := range s.Locations {
+		loc := newLocation(w, ls.ID, ls.Pos)
+		if err := restoreInventory(loc.CompInventory, ls.Inventory); err != nil {
+			return nil, err
+		}
+		w.mgr.RegisterLocation(loc)
+	}
+
+	// Restore all agents. We register all agents first, so we can
+	// resolve references between them in a second pass.
+	agents := make([]*Agent, len(s.Entities))
+	for i, as := range s.Entities {
+		if as.Sheet == nil {
+			return nil, fmt.Errorf("missing character sheet for agent %d", as.ID)
+		}
+		a := &Agent{
+			id:            as.ID,
+			w:             w,
+			CompMovable:   newCompMovable(as.Pos),
+			CompStatus:    &CompStatus{Sleeping: as.Sleeping, cs: as.Sheet},
+			CompInventory: newCompInventory(w, as.ID, as.Inventory.Size),
+			CompAi:        newCompAi(w, as.ID),
+		}
+		a.Speed = as.Speed
+		a.cs.SetTickCounter(as.SheetTicks)
+		if err := restoreInventory(a.CompInventory, as.Inventory); err != nil {
+			return nil, err
+		}
+		w.mgr.RegisterEntity(a)
+		agents[i] = a
+	}
+	for i, as := range s.Entities {
+		a := agents[i]
+		if err := a.CompAi.restoreMemory(&as.AI); err != nil {
+			return nil, err
+		}
+		if ps := as.Profession; ps != nil {
+			if ps.Type < 0 || ps.Type >= len(professionTypes) {
+				return nil, fmt.Errorf("invalid profession type index %d", ps.Type)
+			}
+			a.SetProfession(w, professionTypes[ps.Type])
+			if a.workshop = w.mgr.locationsByID[ps.Workshop]; a.workshop == nil {
+				return nil, fmt.Errorf("unknown workshop %d for agent %d", ps.Workshop, as.ID)
+			}
+			if pr := ps.Project; pr != nil {
+				t, err := getItemType(pr.Produce)
+				if err != nil {
+					return nil, err
+				}
+				a.CurrentProject = &Project{
+					Produce:  t,
+					Progress: pr.Progress,
+					Duration: pr.Duration,
+					Complete: pr.Complete,
+				}
+			}
+			for _, idx := range ps.Missing {
+				t, err := getItemType(idx)
+				if err != nil {
+					return nil, err
+				}
+				a.Missing = append(a.Missing, t)
+			}
+		}
+		// Restore the AI states last, since the profession is also a state.
+		if err := a.CompAi.restoreStates(&as.AI); err != nil {
+			return nil, err
+		}
+	}
+
+	w.mgr.nextID = s.NextID
+
+	// Restore the RNG state last, since restoring the agents has
+	// consumed random numbers.
+	w.src.State = s.RandState
+	return w, nil
+}
+
+// restoreMemory restores personality, status, memory and path
+// planning from the given snapshot.
+func (c *CompAi) restoreMemory(as *AiSnapshot) error {
+	c.SmallModel = as.Personality
+	for k, v := range as.Status {
+		c.CAiStatus.states[k] = v
+	}
+	c.eat = as.Eat
+	for tag, id := range as.Locations {
+		loc := c.w.mgr.locationsByID[id]
+		if loc == nil {
+			return fmt.Errorf("unknown location %d in memory of %d", id, c.id)
+		}
+		c.CAiMemory.Locations[tag] = loc
+	}
+	for tag, pos := range as.Positions {
+		c.CAiMemory.Positions[tag] = pos
+	}
+	c.Waypoints = as.Waypoints
+	c.WaypointCurrent = as.WaypointCurrent
+	c.Target = as.Target
+	c.active = as.Active
+	c.running = as.Running
+	c.planned = as.Planned
+	return nil
+}
+
+// restoreStates restores the current and previous AI state as well as
+// the references held by the individual states.
+//
+// NOTE: We set the states directly instead of using SetState since we
+// don't want to trigger any OnEnter / OnExit side effects.
+func (c *CompAi) restoreStates(as *AiSnapshot) error {
+	getState := func(t *aistate.StateType) (aistate.State, error) {
+		if t == nil {
+			return nil, nil
+		}
+		st := c.getState(*t)
+		if st == nil {
+			return nil, fmt.Errorf("unknown state %d for agent %d", *t, c.id)
+		}
+		return st, nil
+	}
+	cur, err := getState(as.State)
+	if err != nil {
+		return err
+	}
+	prev, err := getState(as.PreviousState)
+	if err != nil {
+		return err
+	}
+	c.Current = cur
+	c.Previous = prev
+	for t, id := range as.StateRefs {
+		if r, ok := c.getState(t).(stateReferrer); ok {
+			r.restoreRef(id)
+		}
+	}
+	return nil
+}
+
+// Save writes a snapshot of the world to the given path.
+func (w *World) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(w.Snapshot()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load restores a world from the snapshot stored under the given path.
+func Load(path string) (*World, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var s Snapshot
+	if err := json.NewDecoder(f).Decode(&s); err != nil {
+		return nil, err
+	}
+	return NewFromSnapshot(&s)
+}
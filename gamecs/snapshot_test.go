@@ -0,0 +1,73 @@
+package gamecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"testing"
+)
+
+// newTestWorld returns a world populated with a couple of characters, some of
+// them with a profession.
+func newTestWorld(seed int64) *World {
+	w := NewWithSeed(seed)
+
+	itGrain := NewItemType("grain", "grain")
+	itBread := NewItemType("bread", "food")
+	itBread.Requires = []*ItemType{itGrain}
+	pBaker := NewProfessionType("baker", itBread)
+	pFarmer := NewProfessionType("farmer", itGrain)
+
+	w.NewChar().SetProfession(w, pBaker)
+	w.NewChar().SetProfession(w, pFarmer)
+	for i := 0; i < 5; i++ {
+		w.NewChar()
+	}
+	return w
+}
+
+func TestSnapshotContinuesDeterministically(t *testing.T) {
+	// The AI is very chatty.
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	const ticksBefore, ticksAfter = 50, 200
+	w := newTestWorld(1234)
+	for i := 0; i < ticksBefore; i++ {
+		w.Update(0.2)
+	}
+
+	// Restore a copy of the world from its serialized snapshot.
+	data, err := json.Marshal(w.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := NewFromSnapshot(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both worlds have to end up in the same state.
+	for i := 0; i < ticksAfter; i++ {
+		w.Update(0.2)
+		restored.Update(0.2)
+
+		want, err := json.Marshal(w.Snapshot())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := json.Marshal(restored.Snapshot())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("tick %d after restore: restored world diverged from the original", i)
+		}
+	}
+}
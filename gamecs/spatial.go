@@ -0,0 +1,174 @@
+package gamecs
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// spatialCellSize is the size of a cell in the spatial index, which
+// matches the perception distance so that a radius query for perception
+// only has to look at the 3x3 surrounding cells.
+const spatialCellSize = perceptionDist
+
+// spatialCell is the coordinate of a cell in the spatial index.
+type spatialCell struct {
+	X, Y int
+}
+
+// spatialHash is a uniform grid based spatial index (spatial hash) that
+// allows for fast radius and k-nearest neighbor queries of IDs.
+type spatialHash struct {
+	cellSize float64
+	cells    map[spatialCell][]int // IDs by cell
+	pos      map[int]vectors.Vec2  // Indexed position by ID
+}
+
+// newSpatialHash returns a new spatial index with the given cell size.
+func newSpatialHash(cellSize float64) *spatialHash {
+	return &spatialHash{
+		cellSize: cellSize,
+		cells:    make(map[spatialCell][]int),
+		pos:      make(map[int]vectors.Vec2),
+	}
+}
+
+// cellOf returns the cell containing the given position.
+func (h *spatialHash) cellOf(p vectors.Vec2) spatialCell {
+	return spatialCell{
+		X: int(math.Floor(p.X / h.cellSize)),
+		Y: int(math.Floor(p.Y / h.cellSize)),
+	}
+}
+
+// insert adds the ID at the given position or moves it there if it is
+// already indexed.
+func (h *spatialHash) insert(id int, p vectors.Vec2) {
+	if old, ok := h.pos[id]; ok {
+		if h.cellOf(old) == h.cellOf(p) {
+			h.pos[id] = p
+			return
+		}
+		h.remove(id)
+	}
+	c := h.cellOf(p)
+	h.cells[c] = append(h.cells[c], id)
+	h.pos[id] = p
+}
+
+// remove removes the ID from the index.
+func (h *spatialHash) remove(id int) {
+	p, ok := h.pos[id]
+	if !ok {
+		return
+	}
+	delete(h.pos, id)
+	c := h.cellOf(p)
+	ids := h.cells[c]
+	for i, cid := range ids {
+		if cid == id {
+			ids[i] = ids[len(ids)-1]
+			ids = ids[:len(ids)-1]
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(h.cells, c)
+	} else {
+		h.cells[c] = ids
+	}
+}
+
+// spatialResult is a candidate of a spatial query.
+type spatialResult struct {
+	id   int
+	dist float64
+}
+
+// collectRing appends all IDs within the ring of cells with the given
+// distance (in cells) around the center cell that are closer than maxDist
+// to p and pass the filter. It also returns the number of visited IDs.
+func (h *spatialHash) collectRing(res []spatialResult, center spatialCell, ring int, p vectors.Vec2, maxDist float64, filter func(id int) bool) ([]spatialResult, int) {
+	var visited int
+	for y := center.Y - ring; y <= center.Y+ring; y++ {
+		for x := center.X - ring; x <= center.X+ring; x++ {
+			// Only visit the border of the ring.
+			if ring > 0 && y != center.Y-ring && y != center.Y+ring && x != center.X-ring && x != center.X+ring {
+				continue
+			}
+			ids := h.cells[spatialCell{X: x, Y: y}]
+			visited += len(ids)
+			for _, id := range ids {
+				d := vectors.Dist2(h.pos[id], p)
+				if d >= maxDist || (filter != nil && !filter(id)) {
+					continue
+				}
+				res = append(res, spatialResult{id: id, dist: d})
+			}
+		}
+	}
+	return res, visited
+}
+
+// sortResults sorts the results by distance. Ties are broken by ID so
+// that the results are deterministic.
+func sortResults(res []spatialResult) {
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].dist != res[j].dist {
+			return res[i].dist < res[j].dist
+		}
+		return res[i].id < res[j].id
+	})
+}
+
+// inRadius returns the IDs closer than r to p that pass the (optional)
+// filter, sorted by distance.
+func (h *spatialHash) inRadius(p vectors.Vec2, r float64, filter func(id int) bool) []int {
+	center := h.cellOf(p)
+	var res []spatialResult
+	for ring := 0; ring <= int(math.Ceil(r/h.cellSize)); ring++ {
+		res, _ = h.collectRing(res, center, ring, p, r, filter)
+	}
+	sortResults(res)
+	ids := make([]int, len(res))
+	for i, r := range res {
+		ids[i] = r.id
+	}
+	return ids
+}
+
+// nearest returns up to k IDs closer than maxDist to p that pass the
+// (optional) filter, sorted by distance.
+func (h *spatialHash) nearest(p vectors.Vec2, k int, maxDist float64, filter func(id int) bool) []int {
+	if k <= 0 {
+		return nil
+	}
+	center := h.cellOf(p)
+	maxRing := int(math.Ceil(maxDist / h.cellSize))
+	var res []spatialResult
+	var visited int
+	for ring := 0; ring <= maxRing && visited < len(h.pos); ring++ {
+		var n int
+		res, n = h.collectRing(res, center, ring, p, maxDist, filter)
+		visited += n
+		if len(res) < k {
+			continue
+		}
+		// All positions in the next ring are at least 'ring' cells away,
+		// so if we have k results closer than that, we are done.
+		sortResults(res)
+		if res[k-1].dist <= float64(ring)*h.cellSize {
+			break
+		}
+	}
+	sortResults(res)
+	if len(res) > k {
+		res = res[:k]
+	}
+	ids := make([]int, len(res))
+	for i, r := range res {
+		ids[i] = r.id
+	}
+	return ids
+}
@@ -0,0 +1,95 @@
+package gamecs
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// bruteForceQuery returns up to k IDs (all if k < 0) closer than maxDist to p
+// that pass the filter, sorted by distance.
+func bruteForceQuery(pos map[int]vectors.Vec2, p vectors.Vec2, k int, maxDist float64, filter func(id int) bool) []int {
+	var res []spatialResult
+	for id, q := range pos {
+		if d := vectors.Dist2(q, p); d < maxDist && (filter == nil || filter(id)) {
+			res = append(res, spatialResult{id: id, dist: d})
+		}
+	}
+	sortResults(res)
+	if k >= 0 && len(res) > k {
+		res = res[:k]
+	}
+	ids := make([]int, len(res))
+	for i, r := range res {
+		ids[i] = r.id
+	}
+	return ids
+}
+
+func TestSpatialHash(t *testing.T) {
+	const cellSize = 10.0
+	rng := rand.New(rand.NewSource(1))
+
+	// randomPos returns a random position around the origin. Every third
+	// position lies exactly on a cell border.
+	randomPos := func() vectors.Vec2 {
+		if rng.Intn(3) == 0 {
+			return vectors.Vec2{
+				X: float64(rng.Intn(13)-6) * cellSize,
+				Y: float64(rng.Intn(13)-6) * cellSize,
+			}
+		}
+		return vectors.Vec2{X: rng.Float64()*120 - 60, Y: rng.Float64()*120 - 60}
+	}
+
+	h := newSpatialHash(cellSize)
+	pos := make(map[int]vectors.Vec2)
+	for id := 0; id < 300; id++ {
+		p := randomPos()
+		h.insert(id, p)
+		pos[id] = p
+	}
+	// Move and remove some of the IDs.
+	for i := 0; i < 100; i++ {
+		id := rng.Intn(300)
+		if rng.Intn(4) == 0 {
+			h.remove(id)
+			delete(pos, id)
+		} else {
+			p := randomPos()
+			h.insert(id, p)
+			pos[id] = p
+		}
+	}
+
+	even := func(id int) bool { return id%2 == 0 }
+	for i := 0; i < 500; i++ {
+		p := randomPos()
+		r := rng.Float64() * 40
+		if i%5 == 0 {
+			r = float64(rng.Intn(4)) * cellSize // radius on a cell border
+		}
+		k := rng.Intn(10) + 1
+		var filter func(id int) bool
+		if i%2 == 0 {
+			filter = even
+		}
+		if got, want := h.inRadius(p, r, filter), bruteForceQuery(pos, p, -1, r, filter); !equalIDs(got, want) {
+			t.Fatalf("inRadius(%v, %f): got %v, want %v", p, r, got, want)
+		}
+		if got, want := h.nearest(p, k, r, filter), bruteForceQuery(pos, p, k, r, filter); !equalIDs(got, want) {
+			t.Fatalf("nearest(%v, %d, %f): got %v, want %v", p, k, r, got, want)
+		}
+	}
+}
+
+// equalIDs returns true if both ID slices are equal (nil and empty are
+// treated the same).
+func equalIDs(a, b []int) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,153 @@
+package gamerogueish
+
+import "fmt"
+
+// Action is something an entity can do during its turn.
+// See: http://journal.stuffwithstuff.com/2014/07/15/a-turn-based-game-loop/
+type Action interface {
+	// Perform performs the action for the given entity.
+	Perform(g *Game, e *Entity) ActionResult
+}
+
+// ActionResult is the result of performing an action.
+type ActionResult struct {
+	Succeeded bool   // indicates if the action succeeded (and consumed energy)
+	Alternate Action // action to perform instead (if any)
+}
+
+var (
+	actionSuccess = ActionResult{Succeeded: true}
+	actionFailure = ActionResult{}
+)
+
+// alternate returns a result indicating that the given action should be
+// performed instead.
+func alternate(a Action) ActionResult {
+	return ActionResult{Alternate: a}
+}
+
+// WaitAction does nothing for a turn.
+type WaitAction struct{}
+
+// Perform implements the Action interface.
+func (a *WaitAction) Perform(g *Game, e *Entity) ActionResult {
+	return actionSuccess
+}
+
+// MoveAction moves the entity by one tile in the given direction.
+// If the destination is occupied by an opponent, it attacks instead.
+type MoveAction struct {
+	DX, DY int // direction
+}
+
+// Perform implements the Action interface.
+func (a *MoveAction) Perform(g *Game, e *Entity) ActionResult {
+	if a.DX == 0 && a.DY == 0 {
+		return alternate(&WaitAction{})
+	}
+	x, y := e.X+a.DX, e.Y+a.DY
+	if !g.CanMoveTo(x, y) {
+		return actionFailure
+	}
+	if t := g.entityAt(x, y); t != nil {
+		// Only the player and monsters are opponents.
+		if (e == g.player) != (t == g.player) {
+			return alternate(&AttackAction{Target: t})
+		}
+		return actionFailure
+	}
+	e.X, e.Y = x, y
+	return actionSuccess
+}
+
+// AttackAction attacks the given target.
+type AttackAction struct {
+	Target *Entity
+}
+
+// Perform implements the Action interface.
+func (a *AttackAction) Perform(g *Game, e *Entity) ActionResult {
+	if a.Target == nil || a.Target.IsDead() || !isAdjacent(e.X, e.Y, a.Target.X, a.Target.Y) {
+		return actionFailure
+	}
+	g.AddMessage(fmt.Sprintf("%s attacks %s", e.Name, a.Target.Name))
+	e.Attack(g, a.Target)
+	return actionSuccess
+}
+
+// AttackAdjacentAction attacks all living opponents next to the entity.
+type AttackAdjacentAction struct{}
+
+// Perform implements the Action interface.
+func (a *AttackAdjacentAction) Perform(g *Game, e *Entity) ActionResult {
+	var attacked bool
+	if e == g.player {
+		for _, t := range g.EntitiesInRange(e.X, e.Y) {
+			if !t.IsDead() {
+				e.Attack(g, t)
+				attacked = true
+			}
+		}
+	} else if isAdjacent(e.X, e.Y, g.player.X, g.player.Y) {
+		e.Attack(g, g.player)
+		attacked = true
+	}
+	if !attacked {
+		return actionFailure
+	}
+	return actionSuccess
+}
+
+// UseItemAction equips or consumes the item at the given inventory index.
+type UseItemAction struct {
+	Index int
+}
+
+// Perform implements the Action interface.
+func (a *UseItemAction) Perform(g *Game, e *Entity) ActionResult {
+	it := e.GetItem(a.Index)
+	switch {
+	case it == nil:
+		return actionFailure
+	case it.Equippable():
+		e.Equip(a.Index)
+	case it.Consumable() && e.Health < e.BaseHealth:
+		e.Consume(a.Index)
+	default:
+		return actionFailure
+	}
+	return actionSuccess
+}
+
+// PickUpAction picks up all items at the position of the entity.
+type PickUpAction struct{}
+
+// Perform implements the Action interface.
+func (a *PickUpAction) Perform(g *Game, e *Entity) ActionResult {
+	items := g.ItemsAt(e.X, e.Y)
+	if len(items) == 0 {
+		return actionFailure
+	}
+	for _, it := range items {
+		g.RemoveItem(it)
+		e.Inventory.Add(it)
+		g.AddMessage(fmt.Sprintf("%s picked up %s", e.Name, it))
+	}
+	return actionSuccess
+}
+
+// StairsAction uses the stairs at the position of the player.
+type StairsAction struct {
+	Down bool // descend if true, ascend otherwise
+}
+
+// Perform implements the Action interface.
+func (a *StairsAction) Perform(g *Game, e *Entity) ActionResult {
+	if e != g.player {
+		return actionFailure
+	}
+	if a.Down && g.Descend() || !a.Down && g.Ascend() {
+		return actionSuccess
+	}
+	return actionFailure
+}
@@ -1,67 +1,234 @@
 package gamerogueish
 
-import (
-	"fmt"
-	"math/rand"
+// Behavior configures the AI of a monster type.
+type Behavior struct {
+	FleeHealth  float64 // flee if health drops below this fraction of the base health
+	MemoryTurns int     // number of turns the last known player position is remembered
+	PackHunter  bool    // alert allies of the same type and hunt together
+	PackRadius  int     // radius in which allies are alerted
+	Wander      bool    // wander around if we don't know where the player is
+}
+
+var (
+	// BehaviorDefault chases the player and never flees.
+	BehaviorDefault = &Behavior{
+		MemoryTurns: 5,
+		Wander:      true,
+	}
+	// BehaviorCoward flees once injured.
+	BehaviorCoward = &Behavior{
+		FleeHealth:  0.5,
+		MemoryTurns: 3,
+		Wander:      true,
+	}
+	// BehaviorPack hunts in packs and flees if badly injured.
+	BehaviorPack = &Behavior{
+		FleeHealth:  0.3,
+		MemoryTurns: 10,
+		PackHunter:  true,
+		PackRadius:  10,
+		Wander:      true,
+	}
+	// BehaviorBrute guards its spot, never flees, and doesn't forget.
+	BehaviorBrute = &Behavior{
+		MemoryTurns: 20,
+	}
 )
 
-func (g *Game) decideAction(e *Entity) {
+// decideAction returns the next action of the given creature.
+func (g *Game) decideAction(e *Entity) Action {
 	if e.IsDead() {
-		return
+		return &WaitAction{}
+	}
+	b := e.Behavior
+	if b == nil {
+		b = BehaviorDefault
+	}
+
+	// Remember where we have last seen the player.
+	canSee := g.canSeePlayer(e)
+	if canSee {
+		e.LastSeen = Point{X: g.player.X, Y: g.player.Y}
+		e.LastSeenUntil = g.Turns + b.MemoryTurns
+		if b.PackHunter {
+			g.alertPack(e, b)
+		}
+	}
+	nextToPlayer := isAdjacent(e.X, e.Y, g.player.X, g.player.Y)
+
+	// If we are badly injured and know where the player is, flee.
+	if float64(e.Health) < b.FleeHealth*float64(e.BaseHealth) && (canSee || g.remembersPlayer(e)) {
+		if a := g.flee(e); a != nil {
+			return a
+		}
+		// We are cornered, so fight if we can.
 	}
 
 	// If we are next to the player, attack.
-	if e.X == g.player.X && e.Y == g.player.Y {
-		g.AddMessage(fmt.Sprintf("%s attacks %s", e.Name, g.player.Name))
-		e.Attack(g, g.player)
-		return
+	if nextToPlayer {
+		return &AttackAction{Target: g.player}
 	}
 
 	// If we can see the player, move towards them.
-	if g.FOV.IsInRadius(e.X, e.Y, g.player.X, g.player.Y) {
-		moveTowards(g.World, e, g.player)
-		return
+	if canSee {
+		if a := g.chase(e); a != nil {
+			return a
+		}
+		return &WaitAction{}
+	}
+
+	// If we remember where we have seen the player, go there.
+	if g.remembersPlayer(e) {
+		if a := g.moveTowards(e, e.LastSeen); a != nil {
+			return a
+		}
+		e.LastSeenUntil = 0 // We arrived (or got stuck), so forget.
+		return &WaitAction{}
 	}
+
 	// Otherwise, wander.
-	wander(g.World, e)
+	if b.Wander {
+		if a := g.wander(e); a != nil {
+			return a
+		}
+	}
+	return &WaitAction{}
 }
 
-func wander(w *World, e *Entity) {
-	// Decide on a random direction, see if we can enter the tile.
-	// 10 attempts.
-	for i := 0; i < 10; i++ {
-		dx := rand.Intn(3) - 1
-		dy := rand.Intn(3) - 1
-		if w.CanMoveTo(e.X+dx, e.Y+dy) {
-			e.X += dx
-			e.Y += dy
-			return
+// canSeePlayer returns true if the given entity can see the player.
+//
+// Since the FOV is symmetric, the entity can see the player if it is in
+// the line of sight of the player, and the player is standing in the light.
+func (g *Game) canSeePlayer(e *Entity) bool {
+	return g.FOV.IsInLineOfSight(e.X, e.Y) && g.FOV.IsLit(g.player.X, g.player.Y)
+}
+
+// alertPack shares the last known player position with all allies of the
+// same type within the pack radius.
+func (g *Game) alertPack(e *Entity, b *Behavior) {
+	for _, ally := range g.Entities {
+		if ally == e || ally.IsDead() || ally.EntityType != e.EntityType {
+			continue
+		}
+		if chebyshevDist(e.X, e.Y, ally.X, ally.Y) > b.PackRadius {
+			continue
+		}
+		ally.LastSeen = e.LastSeen
+		if ally.LastSeenUntil < e.LastSeenUntil {
+			ally.LastSeenUntil = e.LastSeenUntil
+		}
+	}
+}
+
+// remembersPlayer returns true if the given entity still remembers the last
+// known position of the player. The memory expires after a number of game
+// turns, independent of the speed of the entity.
+func (g *Game) remembersPlayer(e *Entity) bool {
+	return e.LastSeenUntil > g.Turns
+}
+
+// isAdjacent returns true if the two positions are next to each other
+// (including diagonally) or identical.
+func isAdjacent(x1, y1, x2, y2 int) bool {
+	return chebyshevDist(x1, y1, x2, y2) <= 1
+}
+
+// isOccupied returns true if the given tile is occupied by the player or
+// a living creature.
+func (g *Game) isOccupied(x, y int) bool {
+	return g.entityAt(x, y) != nil
+}
+
+// entityAt returns the player or living creature at the given position
+// (or nil if there is none).
+func (g *Game) entityAt(x, y int) *Entity {
+	if g.player.X == x && g.player.Y == y {
+		return g.player
+	}
+	for _, e := range g.Entities {
+		if e.X == x && e.Y == y && !e.IsDead() {
+			return e
 		}
 	}
+	return nil
+}
+
+// moveTo returns a move action from the entity's position to the given
+// neighboring tile.
+func moveTo(e *Entity, p Point) Action {
+	return &MoveAction{DX: p.X - e.X, DY: p.Y - e.Y}
+}
+
+// canEnter returns true if the given tile can be entered by a creature.
+func (g *Game) canEnter(x, y int) bool {
+	return g.CanMoveTo(x, y) && !g.isOccupied(x, y)
+}
+
+// playerMap returns the Dijkstra map leading towards the player, which is
+// shared by all creatures during a turn.
+func (g *Game) playerMap() *DijkstraMap {
+	if g.chaseMap == nil {
+		g.chaseMap = NewDijkstraMap(g.World, Point{X: g.player.X, Y: g.player.Y})
+	}
+	return g.chaseMap
 }
 
-func moveTowards(w *World, e, target *Entity) {
-	// Calculate the direction to the target.
-	dx := target.X - e.X
-	dy := target.Y - e.Y
-	// If we are already next to the target, we are done.
-	if dx*dx+dy*dy == 1 {
-		return
+// safetyMap returns the Dijkstra map leading away from the player, which is
+// shared by all creatures during a turn.
+func (g *Game) safetyMap() *DijkstraMap {
+	if g.fleeMap == nil {
+		g.fleeMap = g.playerMap().FleeMap()
 	}
-	// Normalize the direction.
-	if dx > 0 {
-		dx = 1
-	} else if dx < 0 {
-		dx = -1
+	return g.fleeMap
+}
+
+// resetAIMaps invalidates the Dijkstra maps since the player has moved.
+func (g *Game) resetAIMaps() {
+	g.chaseMap = nil
+	g.fleeMap = nil
+}
+
+// chase returns the action moving the entity one step towards the player.
+// Since occupied tiles are avoided, a pack of creatures will surround the
+// player.
+func (g *Game) chase(e *Entity) Action {
+	p, ok := g.playerMap().Next(e.X, e.Y, g.isOccupied)
+	if !ok {
+		return nil
 	}
-	if dy > 0 {
-		dy = 1
-	} else if dy < 0 {
-		dy = -1
+	return moveTo(e, p)
+}
+
+// flee returns the action moving the entity one step away from the player
+// or nil if there is nowhere to run.
+func (g *Game) flee(e *Entity) Action {
+	p, ok := g.safetyMap().Next(e.X, e.Y, g.isOccupied)
+	if !ok {
+		return nil
 	}
-	if w.CanMoveTo(e.X+dx, e.Y+dy) {
-		e.X += dx
-		e.Y += dy
+	return moveTo(e, p)
+}
+
+// moveTowards returns the action moving the entity one step along the
+// shortest path to the target or nil if we have arrived or there is no path.
+func (g *Game) moveTowards(e *Entity, target Point) Action {
+	path := g.FindPath(e.X, e.Y, target.X, target.Y, g.isOccupied)
+	if len(path) == 0 || !g.canEnter(path[0].X, path[0].Y) {
+		return nil
+	}
+	return moveTo(e, path[0])
+}
+
+// wander returns the action moving the entity in a random direction.
+func (g *Game) wander(e *Entity) Action {
+	// Decide on a random direction, see if we can enter the tile.
+	// 10 attempts.
+	for i := 0; i < 10; i++ {
+		dx := g.rng.Intn(3) - 1
+		dy := g.rng.Intn(3) - 1
+		if (dx != 0 || dy != 0) && g.canEnter(e.X+dx, e.Y+dy) {
+			return &MoveAction{DX: dx, DY: dy}
+		}
 	}
-	// TODO: Pathfinding.
+	return nil
 }
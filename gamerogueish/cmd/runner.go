@@ -2,12 +2,14 @@ package main
 
 import (
 	"github.com/Flokey82/go_gens/gamerogueish"
+	"github.com/Flokey82/go_gens/gamerogueish/ui"
 )
 
 func main() {
-	g, err := gamerogueish.NewGame(gamerogueish.GenWorldSimpleDungeon, 100, 100, 1234)
+	g := gamerogueish.NewGame(gamerogueish.GenWorldSimpleDungeon, 100, 100, 1234)
+	u, err := ui.New(g)
 	if err != nil {
 		panic(err)
 	}
-	g.Start()
+	u.Start()
 }
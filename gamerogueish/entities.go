@@ -2,8 +2,7 @@ package gamerogueish
 
 import (
 	"fmt"
-
-	"github.com/Flokey82/gamedice"
+	"math/rand"
 )
 
 type EntityType struct {
@@ -12,6 +11,8 @@ type EntityType struct {
 	BaseHealth  int
 	BaseAttack  int
 	BaseDefense int
+	Speed       int       // energy gained per tick (0 for SpeedNormal)
+	Behavior    *Behavior // AI behavior (nil for default behavior)
 }
 
 var (
@@ -28,6 +29,8 @@ var (
 		BaseHealth:  5,
 		BaseAttack:  1,
 		BaseDefense: 5,
+		Speed:       12,
+		Behavior:    BehaviorPack,
 	}
 	EntityOrc = &EntityType{
 		Tile:        'o',
@@ -35,6 +38,7 @@ var (
 		BaseHealth:  10,
 		BaseAttack:  5,
 		BaseDefense: 14,
+		Behavior:    BehaviorCoward,
 	}
 	EntityTroll = &EntityType{
 		Tile:        't',
@@ -42,6 +46,8 @@ var (
 		BaseHealth:  15,
 		BaseAttack:  7,
 		BaseDefense: 15,
+		Speed:       7,
+		Behavior:    BehaviorBrute,
 	}
 )
 
@@ -52,12 +58,15 @@ var MonsterEntities = []*EntityType{
 }
 
 type Entity struct {
-	*EntityType                    // type of entity
-	Inventory                      // inventory component
-	X           int                // x position in the world
-	Y           int                // y position in the world
-	Health      int                // health points
-	Slots       [ItemTypeMax]*Item // Equipped items.
+	*EntityType                      // type of entity
+	Inventory                        // inventory component
+	X             int                // x position in the world
+	Y             int                // y position in the world
+	Health        int                // health points
+	Slots         [ItemTypeMax]*Item // Equipped items.
+	LastSeen      Point              // last known position of the player
+	LastSeenUntil int                // game turn until which we remember the last known position
+	Energy        int                // energy available for actions
 }
 
 // NewEntity returns a new entity with the given position and tile.
@@ -70,6 +79,14 @@ func NewEntity(x, y int, e *EntityType) *Entity {
 	}
 }
 
+// speed returns the energy the entity gains per tick.
+func (e *Entity) speed() int {
+	if e.Speed <= 0 {
+		return SpeedNormal
+	}
+	return e.Speed
+}
+
 // Equip equips the item at the given inventory index.
 func (e *Entity) Equip(index int) {
 	if index < 0 || index >= len(e.Items) || !e.Items[index].Equippable() {
@@ -86,6 +103,9 @@ func (e *Entity) Equip(index int) {
 		return
 	}
 
+	// Wearing an item reveals its properties.
+	it.Identified = true
+
 	// If there is already an item in the slot, unequip it.
 	if e.Slots[it.Type] != nil {
 		e.Slots[it.Type].Equipped = false
@@ -95,30 +115,46 @@ func (e *Entity) Equip(index int) {
 
 func (e *Entity) Attack(g *Game, target *Entity) {
 	// Check if attack roll is successful.
-	if roll := gamedice.D20.Roll(); roll >= target.DefenseValue() {
+	if roll := D20.Roll(g.rng) + e.HitBonus(); roll >= target.DefenseValue() {
 		g.AddMessage(fmt.Sprintf("%s hit %s (%d/%d)", e.Name, target.Name, roll, target.DefenseValue()))
-		target.TakeDamage(g, e.AttackDamage())
+		target.TakeDamage(g, e.AttackDamage(g.rng))
 	} else {
 		g.AddMessage(fmt.Sprintf("%s missed %s (%d/%d)", e.Name, target.Name, roll, target.DefenseValue()))
 	}
 }
 
-func (e *Entity) AttackDamage() int {
-	damage := e.BaseAttack // Unarmed attack.
+// DamageRoll returns the damage roll of the entity.
+func (e *Entity) DamageRoll() DiceRoll {
 	// Check if we have a weapon equipped.
-	// TODO: Allow weapon specific damage.
-	if e.Slots[ItemWeapon] != nil {
-		damage = 5
+	if w := e.Slots[ItemWeapon]; w != nil {
+		return w.DamageRoll()
+	}
+	return DiceRoll{Bonus: e.BaseAttack} // Unarmed attack.
+}
+
+// AttackDamage rolls the damage of an attack (at least 1) using the given rng.
+func (e *Entity) AttackDamage(rng *rand.Rand) int {
+	return max(e.DamageRoll().Roll(rng), 1)
+}
+
+// HitBonus returns the bonus to the attack roll from equipped items.
+func (e *Entity) HitBonus() int {
+	var bonus int
+	for _, it := range e.Slots {
+		if it != nil {
+			bonus += it.HitBonus()
+		}
 	}
-	return damage
+	return bonus
 }
 
+// DefenseValue returns the value an attack roll has to reach to hit.
 func (e *Entity) DefenseValue() int {
 	defense := e.BaseDefense // Unarmored defense.
-	// Check if we have armor equipped.
-	// TODO: Allow armor specific defense.
-	if e.Slots[ItemArmor] != nil {
-		defense += 2
+	for _, it := range e.Slots {
+		if it != nil {
+			defense += it.DefenseBonus()
+		}
 	}
 	return defense
 }
@@ -2,135 +2,277 @@ package gamerogueish
 
 import "math"
 
-// FOV implements a field of view logic.
-// TODO: Add raycasting or octants.
-// See: http://journal.stuffwithstuff.com/2015/09/07/what-the-hero-sees/
+// FOV implements a field of view logic using symmetric shadowcasting.
+// A tile is visible if it is in line of sight within the sight radius and
+// if it is lit by a light source (including the light carried by the viewer).
+// All tiles that have been visible at some point are remembered as seen.
+//
+// See: https://www.albertford.com/shadowcasting/
 type FOV struct {
-	Seen   [][]bool // keeps track of tiles that we have seen and remember
-	Radius int      // radius of the FOV
-	*World          // world to compute the FOV for
+	Seen        [][]bool // keeps track of tiles that we have seen and remember
+	Visible     [][]bool // tiles that are currently visible
+	InSight     [][]bool // tiles that are in line of sight (lit or not)
+	Lit         [][]bool // tiles that are currently lit
+	Radius      int      // sight radius of the FOV
+	LightRadius int      // radius of the light carried by the viewer (0 for none)
+	*World               // world to compute the FOV for
 }
 
-// NewFOV returns a new FOV struct.
+// NewFOV returns a new FOV struct with the given sight radius.
+// The viewer carries a light with the same radius.
 func NewFOV(w *World, r int) *FOV {
 	f := &FOV{
-		Radius: r,
-		World:  w,
-	}
-	f.Seen = make([][]bool, f.Height)
-	for i := 0; i < f.Height; i++ {
-		f.Seen[i] = make([]bool, f.Width)
+		Radius:      r,
+		LightRadius: r,
+		World:       w,
 	}
+	f.Seen = newBoolGrid(w.Width, w.Height)
+	f.Visible = newBoolGrid(w.Width, w.Height)
+	f.InSight = newBoolGrid(w.Width, w.Height)
+	f.Lit = newBoolGrid(w.Width, w.Height)
 	return f
 }
 
-// Update clears the FOV and recomputes it for the given position.
+// newBoolGrid returns a new grid of booleans with the given dimensions.
+func newBoolGrid(width, height int) [][]bool {
+	g := make([][]bool, height)
+	for i := range g {
+		g[i] = make([]bool, width)
+	}
+	return g
+}
+
+// clearBoolGrid sets all values in the grid to false.
+func clearBoolGrid(g [][]bool) {
+	for i := range g {
+		for j := range g[i] {
+			g[i][j] = false
+		}
+	}
+}
+
+// Update clears the currently visible tiles and recomputes them for the
+// given position.
 func (f *FOV) Update(x, y int) {
-	f.Clear()
 	f.Compute(x, y)
 }
 
-// Clear resets all tiles to unseen.
+// Clear resets all tiles to unseen (we forget everything we have seen).
 func (f *FOV) Clear() {
-	for i := 0; i < f.Height; i++ {
-		for j := 0; j < f.Width; j++ {
-			f.Seen[i][j] = false
-		}
-	}
+	clearBoolGrid(f.Seen)
+	clearBoolGrid(f.Visible)
+	clearBoolGrid(f.InSight)
+	clearBoolGrid(f.Lit)
 }
 
-// Compute sets all tiles within the FOV radius to seen.
+// Compute recomputes the visible tiles for the given position and marks
+// them as seen.
 func (f *FOV) Compute(x, y int) {
-	for i := 0; i < f.Height; i++ {
-		for j := 0; j < f.Width; j++ {
-			if f.IsInRadius(x, y, j, i) {
-				f.Seen[i][j] = true
-			}
+	clearBoolGrid(f.Visible)
+	clearBoolGrid(f.InSight)
+	clearBoolGrid(f.Lit)
+
+	// Compute the lit tiles.
+	light := func(tx, ty int) {
+		f.Lit[ty][tx] = true
+	}
+	if f.LightRadius > 0 {
+		f.ComputeFOV(x, y, f.LightRadius, light)
+	}
+	for _, l := range f.Lights {
+		f.ComputeFOV(l.X, l.Y, l.Radius, light)
+	}
+
+	// Compute the tiles in line of sight and determine which ones are visible.
+	f.ComputeFOV(x, y, f.Radius, func(tx, ty int) {
+		f.InSight[ty][tx] = true
+		if f.Lit[ty][tx] || f.isNextToLit(tx, ty) {
+			f.Visible[ty][tx] = true
+			f.Seen[ty][tx] = true
+		}
+	})
+}
+
+// isNextToLit returns true if the given opaque tile borders on a lit
+// transparent tile. This way we see walls that are illuminated from the
+// side facing us.
+func (f *FOV) isNextToLit(x, y int) bool {
+	if !f.IsOpaque(x, y) {
+		return false
+	}
+	for _, nb := range neighbors8 {
+		nx, ny := x+nb.X, y+nb.Y
+		if f.InBounds(nx, ny) && f.Lit[ny][nx] && !f.IsOpaque(nx, ny) {
+			return true
 		}
 	}
+	return false
+}
+
+// IsVisible returns true if the given tile is currently visible.
+func (f *FOV) IsVisible(x, y int) bool {
+	return f.InBounds(x, y) && f.Visible[y][x]
+}
+
+// IsSeen returns true if the given tile has been seen before.
+func (f *FOV) IsSeen(x, y int) bool {
+	return f.InBounds(x, y) && f.Seen[y][x]
+}
+
+// IsInLineOfSight returns true if the given tile is in line of sight of
+// the viewer (regardless of lighting).
+//
+// NOTE: Since the shadowcasting is symmetric, this is also true if a
+// creature on the given tile can see the viewer.
+func (f *FOV) IsInLineOfSight(x, y int) bool {
+	return f.InBounds(x, y) && f.InSight[y][x]
+}
+
+// IsLit returns true if the given tile is currently lit.
+func (f *FOV) IsLit(x, y int) bool {
+	return f.InBounds(x, y) && f.Lit[y][x]
 }
 
 // IsInRadius returns true if the given coordinates are within the FOV radius.
 func (f *FOV) IsInRadius(x, y, x2, y2 int) bool {
-	return math.Sqrt(math.Pow(float64(x2-x), 2)+math.Pow(float64(y2-y), 2)) <= float64(f.Radius)
+	return isInRadius(x, y, x2, y2, f.Radius)
 }
 
-/*
-func (f *FOV) CanSee(x, y, tgtX, tgtY int) bool {
-	if !f.InBounds(x, y) || !f.InBounds(tgtX, tgtY) {
-		return false
-	}
-	if !f.IsInRadius(x, y, tgtX, tgtY) {
-		return false
-	}
-	if !f.IsInLineOfSight(x, y, tgtX, tgtY) {
-		return false
-	}
-	return true
+// isInRadius returns true if the given coordinates are within the radius.
+func isInRadius(x, y, x2, y2, r int) bool {
+	return math.Sqrt(math.Pow(float64(x2-x), 2)+math.Pow(float64(y2-y), 2)) <= float64(r)
 }
 
-func (f *FOV) IsInLineOfSight(x, y, tgtX, tgtY int) bool {
-	if x == tgtX && y == tgtY {
-		return true
-	}
-	if x == tgtX {
-		return f.IsInLineOfSightVertical(x, y, tgtY)
-	}
-	if y == tgtY {
-		return f.IsInLineOfSightHorizontal(x, y, tgtX)
-	}
-	return f.IsInLineOfSightDiagonal(x, y, tgtX, tgtY)
+// Light is a light source illuminating all tiles within its radius that
+// are in line of sight.
+type Light struct {
+	X, Y   int // position of the light
+	Radius int // radius of the light
 }
 
-func (f *FOV) IsInLineOfSightVertical(x, y, tgtY int) bool {
-	if y == tgtY {
-		return true
+// ComputeFOV calls markVisible for all tiles that are visible from the
+// origin within the given radius using symmetric shadowcasting.
+// Opaque tiles (see IsOpaque) block the line of sight.
+func (w *World) ComputeFOV(ox, oy, radius int, markVisible func(x, y int)) {
+	if !w.InBounds(ox, oy) {
+		return
 	}
-	if y > tgtY {
-		y, tgtY = tgtY, y
-	}
-	for i := y + 1; i < tgtY; i++ {
-		if f.IsSolid(x, i) {
-			return false
+	markVisible(ox, oy)
+	for q := 0; q < 4; q++ {
+		sc := &shadowcaster{
+			w:           w,
+			quadrant:    q,
+			ox:          ox,
+			oy:          oy,
+			radius:      radius,
+			markVisible: markVisible,
 		}
+		sc.scan(1, fraction{-1, 1}, fraction{1, 1})
 	}
-	return true
 }
 
-func (f *FOV) IsInLineOfSightHorizontal(x, y, tgtX int) bool {
-	if x == tgtX {
-		return true
-	}
-	if x > tgtX {
-		x, tgtX = tgtX, x
+// CanSee returns true if the target is in line of sight of the origin
+// within the given radius.
+func (w *World) CanSee(x, y, tx, ty, radius int) bool {
+	if !isInRadius(x, y, tx, ty, radius) {
+		return false
 	}
-	for i := x + 1; i < tgtX; i++ {
-		if f.IsSolid(i, y) {
-			return false
+	var visible bool
+	w.ComputeFOV(x, y, chebyshevDist(x, y, tx, ty), func(vx, vy int) {
+		if vx == tx && vy == ty {
+			visible = true
 		}
+	})
+	return visible
+}
+
+// fraction is an exact rational number used for the slopes in the
+// shadowcasting algorithm. The denominator is always positive.
+type fraction struct {
+	num, den int
+}
+
+// floorDiv returns the floor of a/b for b > 0.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
 	}
-	return true
+	return q
 }
 
-func (f *FOV) IsInLineOfSightDiagonal(x, y, tgtX, tgtY int) bool {
-	if x == tgtX || y == tgtY {
-		return true
+// shadowcaster computes the visible tiles within a single quadrant.
+type shadowcaster struct {
+	w           *World
+	quadrant    int // 0: north, 1: east, 2: south, 3: west
+	ox, oy      int // origin
+	radius      int
+	markVisible func(x, y int)
+}
+
+// transform converts the row depth and column within the quadrant to world
+// coordinates.
+func (s *shadowcaster) transform(depth, col int) (int, int) {
+	switch s.quadrant {
+	case 0:
+		return s.ox + col, s.oy - depth
+	case 1:
+		return s.ox + depth, s.oy + col
+	case 2:
+		return s.ox + col, s.oy + depth
+	default:
+		return s.ox - depth, s.oy + col
 	}
-	if x > tgtX {
-		x, tgtX = tgtX, x
-		y, tgtY = tgtY, y
+}
+
+// isOpaque returns true if the tile at the given row depth and column
+// blocks the line of sight.
+func (s *shadowcaster) isOpaque(depth, col int) bool {
+	return s.w.IsOpaque(s.transform(depth, col))
+}
+
+// reveal marks the tile at the given row depth and column as visible if it
+// is within bounds and within the radius.
+func (s *shadowcaster) reveal(depth, col int) {
+	x, y := s.transform(depth, col)
+	if s.w.InBounds(x, y) && isInRadius(s.ox, s.oy, x, y, s.radius) {
+		s.markVisible(x, y)
 	}
-	if y > tgtY {
-		y, tgtY = tgtY, y
+}
+
+// scan scans the row at the given depth between the start and end slope
+// and recursively continues with the next rows.
+func (s *shadowcaster) scan(depth int, start, end fraction) {
+	if depth > s.radius {
+		return
 	}
-	// y = mx + b
-	m := float64(tgtY-y) / float64(tgtX-x)
-	b := float64(y) - m*float64(x)
-	for i := x + 1; i < tgtX; i++ {
-		j := int(math.Round(m*float64(i) + b))
-		if f.IsSolid(i, j) {
-			return false
+	// The columns of the row are depth*slope rounded (ties up for the
+	// start, ties down for the end).
+	minCol := floorDiv(2*depth*start.num+start.den, 2*start.den)
+	maxCol := -floorDiv(-2*depth*end.num+end.den, 2*end.den)
+
+	if minCol > maxCol {
+		return
+	}
+	prevOpaque := false
+	for col := minCol; col <= maxCol; col++ {
+		opaque := s.isOpaque(depth, col)
+		// Walls are always revealed, floors only if they are symmetric
+		// (i.e. the center of the tile is within the start and end slope).
+		if opaque || (col*start.den >= depth*start.num && col*end.den <= depth*end.num) {
+			s.reveal(depth, col)
 		}
+		if col > minCol {
+			if prevOpaque && !opaque {
+				start = fraction{2*col - 1, 2 * depth}
+			}
+			if !prevOpaque && opaque {
+				s.scan(depth+1, start, fraction{2*col - 1, 2 * depth})
+			}
+		}
+		prevOpaque = opaque
+	}
+	if !prevOpaque {
+		s.scan(depth+1, start, end)
 	}
-	return true
-}*/
+}
@@ -0,0 +1,207 @@
+package gamerogueish
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// DiceRoll represents a roll like 2d6+1.
+type DiceRoll struct {
+	Count int // number of dice
+	Sides int // sides of each die
+	Bonus int // bonus added to the roll
+}
+
+// D20 is a single twenty-sided die used for attack rolls.
+var D20 = DiceRoll{Count: 1, Sides: 20}
+
+// Roll rolls the dice using the given rng and returns the sum plus the bonus.
+func (d DiceRoll) Roll(rng *rand.Rand) int {
+	sum := d.Bonus
+	if d.Sides > 0 {
+		for i := 0; i < d.Count; i++ {
+			sum += rng.Intn(d.Sides) + 1
+		}
+	}
+	return sum
+}
+
+// Add returns the dice roll with the given bonus added.
+func (d DiceRoll) Add(bonus int) DiceRoll {
+	d.Bonus += bonus
+	return d
+}
+
+// String returns the dice roll in the common notation (e.g. 2d6+1).
+func (d DiceRoll) String() string {
+	if d.Count == 0 {
+		return fmt.Sprintf("%d", d.Bonus)
+	}
+	if d.Bonus == 0 {
+		return fmt.Sprintf("%dd%d", d.Count, d.Sides)
+	}
+	return fmt.Sprintf("%dd%d%+d", d.Count, d.Sides, d.Bonus)
+}
+
+// Rarity is the rarity tier of an item, which determines the number of
+// affixes it has.
+type Rarity int
+
+// Rarity tiers.
+const (
+	RarityCommon Rarity = iota // no affixes
+	RarityMagic                // a prefix or a suffix
+	RarityRare                 // a prefix and a suffix
+	RarityMax
+)
+
+// String returns the name of the rarity tier.
+func (r Rarity) String() string {
+	switch r {
+	case RarityCommon:
+		return "common"
+	case RarityMagic:
+		return "magic"
+	case RarityRare:
+		return "rare"
+	}
+	return "unknown"
+}
+
+// Affix is a prefix or suffix modifying the stats of an item.
+type Affix struct {
+	Name         string // name (e.g. "Sharp" or "of Protection")
+	Prefix       bool   // true if this is a prefix, false if it is a suffix
+	Types        []int  // item types the affix can be applied to
+	MinDepth     int    // minimum depth the affix can be found at
+	DamageBonus  int    // bonus to the damage roll
+	HitBonus     int    // bonus to the attack roll
+	DefenseBonus int    // bonus to the defense value
+}
+
+// appliesTo returns true if the affix can be applied to the given item type.
+func (a *Affix) appliesTo(itemType int) bool {
+	for _, t := range a.Types {
+		if t == itemType {
+			return true
+		}
+	}
+	return false
+}
+
+// Affixes are all prefixes and suffixes that can be rolled on items.
+var Affixes = []*Affix{
+	{Name: "Sharp", Prefix: true, Types: []int{ItemWeapon}, DamageBonus: 1},
+	{Name: "Keen", Prefix: true, Types: []int{ItemWeapon}, HitBonus: 2},
+	{Name: "Vicious", Prefix: true, Types: []int{ItemWeapon}, MinDepth: 3, DamageBonus: 3},
+	{Name: "Sturdy", Prefix: true, Types: []int{ItemArmor}, DefenseBonus: 1},
+	{Name: "Reinforced", Prefix: true, Types: []int{ItemArmor}, MinDepth: 2, DefenseBonus: 2},
+	{Name: "Rusty", Prefix: true, Types: []int{ItemWeapon}, DamageBonus: -1},
+	{Name: "Dented", Prefix: true, Types: []int{ItemArmor}, DefenseBonus: -1},
+	{Name: "of Accuracy", Types: []int{ItemWeapon}, HitBonus: 3},
+	{Name: "of Slaying", Types: []int{ItemWeapon}, MinDepth: 4, DamageBonus: 2, HitBonus: 2},
+	{Name: "of Protection", Types: []int{ItemArmor, ItemWeapon}, DefenseBonus: 1},
+	{Name: "of the Fortress", Types: []int{ItemArmor}, MinDepth: 4, DefenseBonus: 3},
+}
+
+// LootTable defines which items can be found starting at a given depth and
+// how likely the rarity tiers are.
+type LootTable struct {
+	MinDepth int            // minimum depth at which this table is used
+	Items    []*ItemType    // item types that can be found
+	Rarities [RarityMax]int // weights of the rarity tiers
+}
+
+// DefaultLootTables are the default loot tables sorted by depth.
+// The deepest table with a MinDepth <= the current depth is used.
+var DefaultLootTables = []*LootTable{
+	{
+		MinDepth: 0,
+		Items:    []*ItemType{ItemTypePotion, ItemTypeWeaponDagger, ItemTypeWeaponAxe, ItemTypeArmorLeather},
+		Rarities: [RarityMax]int{80, 18, 2},
+	},
+	{
+		MinDepth: 2,
+		Items:    []*ItemType{ItemTypePotion, ItemTypeWeaponAxe, ItemTypeWeaponSword, ItemTypeArmorLeather, ItemTypeArmorChain},
+		Rarities: [RarityMax]int{60, 30, 10},
+	},
+	{
+		MinDepth: 4,
+		Items:    []*ItemType{ItemTypePotion, ItemTypeWeaponSword, ItemTypeArmorChain, ItemTypeArmorPlate},
+		Rarities: [RarityMax]int{40, 40, 20},
+	},
+}
+
+// lootTableForDepth returns the loot table for the given depth.
+func lootTableForDepth(tables []*LootTable, depth int) *LootTable {
+	var best *LootTable
+	for _, t := range tables {
+		if t.MinDepth <= depth && (best == nil || t.MinDepth > best.MinDepth) {
+			best = t
+		}
+	}
+	return best
+}
+
+// Generate returns a random item from the loot table for the given depth.
+func (t *LootTable) Generate(rng *rand.Rand, depth int) *Item {
+	if len(t.Items) == 0 {
+		return nil
+	}
+	it := t.Items[rng.Intn(len(t.Items))].New()
+	if !it.Equippable() {
+		return it
+	}
+	it.Rarity = t.rollRarity(rng)
+	switch it.Rarity {
+	case RarityMagic:
+		// Either a prefix or a suffix.
+		if rng.Intn(2) == 0 {
+			it.Prefix = rollAffix(rng, it.Type, depth, true)
+		} else {
+			it.Suffix = rollAffix(rng, it.Type, depth, false)
+		}
+	case RarityRare:
+		it.Prefix = rollAffix(rng, it.Type, depth, true)
+		it.Suffix = rollAffix(rng, it.Type, depth, false)
+	}
+
+	// Only common items are known right away, all others have to be
+	// identified by equipping them.
+	it.Identified = it.Prefix == nil && it.Suffix == nil
+	return it
+}
+
+// rollRarity returns a random rarity tier using the weights of the table.
+func (t *LootTable) rollRarity(rng *rand.Rand) Rarity {
+	var total int
+	for _, w := range t.Rarities {
+		total += w
+	}
+	if total <= 0 {
+		return RarityCommon
+	}
+	roll := rng.Intn(total)
+	for r, w := range t.Rarities {
+		if roll < w {
+			return Rarity(r)
+		}
+		roll -= w
+	}
+	return RarityCommon
+}
+
+// rollAffix returns a random prefix (or suffix) applicable to the given
+// item type and depth, or nil if there is none.
+func rollAffix(rng *rand.Rand, itemType, depth int, prefix bool) *Affix {
+	var candidates []*Affix
+	for _, a := range Affixes {
+		if a.Prefix == prefix && a.MinDepth <= depth && a.appliesTo(itemType) {
+			candidates = append(candidates, a)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[rng.Intn(len(candidates))]
+}
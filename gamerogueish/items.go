@@ -10,12 +10,68 @@ const (
 // Item represents an item in the game.
 type Item struct {
 	*ItemType
-	Equipped bool // indicates if the item is equipped
+	Equipped   bool   // indicates if the item is equipped
+	X, Y       int    // position in the world (if lying on the ground)
+	Rarity     Rarity // rarity tier of the item
+	Prefix     *Affix // prefix (if any)
+	Suffix     *Affix // suffix (if any)
+	Identified bool   // indicates if the affixes are known
 }
 
-// String returns the name of the item.
+// String returns the name of the item. Affixes are only part of the name
+// once the item has been identified.
 func (i Item) String() string {
-	return i.Name
+	if !i.Identified {
+		return "unidentified " + i.Name
+	}
+	name := i.Name
+	if i.Prefix != nil {
+		name = i.Prefix.Name + " " + name
+	}
+	if i.Suffix != nil {
+		name += " " + i.Suffix.Name
+	}
+	return name
+}
+
+// affixes returns the affixes of the item.
+func (i Item) affixes() []*Affix {
+	var affixes []*Affix
+	if i.Prefix != nil {
+		affixes = append(affixes, i.Prefix)
+	}
+	if i.Suffix != nil {
+		affixes = append(affixes, i.Suffix)
+	}
+	return affixes
+}
+
+// DamageRoll returns the damage roll of the item including all affixes.
+func (i Item) DamageRoll() DiceRoll {
+	d := i.Damage
+	for _, a := range i.affixes() {
+		d = d.Add(a.DamageBonus)
+	}
+	return d
+}
+
+// HitBonus returns the bonus to the attack roll of the item.
+func (i Item) HitBonus() int {
+	var bonus int
+	for _, a := range i.affixes() {
+		bonus += a.HitBonus
+	}
+	return bonus
+}
+
+// DefenseBonus returns the bonus to the defense value of the item
+// including all affixes.
+func (i Item) DefenseBonus() int {
+	bonus := i.Defense
+	for _, a := range i.affixes() {
+		bonus += a.DefenseBonus
+	}
+	return bonus
 }
 
 // Equippable returns true if the item can be equipped.
@@ -47,6 +103,11 @@ func (i *Inventory) SelectItem(index int) {
 	i.selectedItem = index
 }
 
+// SelectedIndex returns the index of the currently selected item.
+func (i *Inventory) SelectedIndex() int {
+	return i.selectedItem
+}
+
 // Selected returns the currently selected item.
 func (i *Inventory) Selected() *Item {
 	if len(i.Items) == 0 {
@@ -98,44 +159,78 @@ type ItemType struct {
 	Name        string
 	Description string
 	Type        int
+	Tile        byte     // tile used to display the item on the ground
+	Damage      DiceRoll // damage roll (weapons)
+	Defense     int      // defense bonus (armor)
 }
 
-// New returns a new item of the given type.
+// New returns a new (common and identified) item of the given type.
 func (i ItemType) New() *Item {
 	return &Item{
-		ItemType: &i,
+		ItemType:   &i,
+		Identified: true,
 	}
 }
 
 var (
+	ItemTypeWeaponDagger = &ItemType{
+		Name:        "Dagger",
+		Description: "A small dagger.",
+		Type:        ItemWeapon,
+		Tile:        '/',
+		Damage:      DiceRoll{Count: 1, Sides: 4},
+	}
 	ItemTypeWeaponSword = &ItemType{
 		Name:        "Sword",
 		Description: "A sharp sword.",
 		Type:        ItemWeapon,
+		Tile:        '/',
+		Damage:      DiceRoll{Count: 1, Sides: 8},
 	}
 	ItemTypeWeaponAxe = &ItemType{
 		Name:        "Axe",
 		Description: "A sharp axe.",
 		Type:        ItemWeapon,
+		Tile:        '/',
+		Damage:      DiceRoll{Count: 1, Sides: 6, Bonus: 1},
 	}
 	ItemTypePotion = &ItemType{
 		Name:        "Potion",
 		Description: "A healing potion.",
 		Type:        ItemPotion,
+		Tile:        '!',
 	}
 	ItemTypeArmorLeather = &ItemType{
 		Name:        "Leather Armor",
 		Description: "A leather armor.",
 		Type:        ItemArmor,
+		Tile:        '[',
+		Defense:     2,
 	}
 	ItemTypeArmorChain = &ItemType{
 		Name:        "Chain Armor",
 		Description: "A chain armor.",
 		Type:        ItemArmor,
+		Tile:        '[',
+		Defense:     4,
 	}
 	ItemTypeArmorPlate = &ItemType{
 		Name:        "Plate Armor",
 		Description: "A plate armor.",
 		Type:        ItemArmor,
+		Tile:        '[',
+		Defense:     6,
 	}
 )
+
+// ItemTypes contains all item types (used to look up item types by name
+// when loading a savegame).
+var ItemTypes = []*ItemType{
+	ItemTypeWeaponDagger,
+	ItemTypeWeaponSword,
+	ItemTypeWeaponAxe,
+	ItemTypePotion,
+	ItemTypeArmorLeather,
+	ItemTypeArmorChain,
+	ItemTypeArmorPlate,
+}
@@ -0,0 +1,184 @@
+package gamerogueish
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Level represents a single floor of the dungeon.
+// Levels are kept around once generated, so that the creatures, items and
+// the explored areas persist if the player returns.
+type Level struct {
+	*World         // world of the level
+	FOV      *FOV  // FOV (and memory of seen tiles) of the level
+	Depth    int   // depth of the level (0 is the top level)
+	Entrance Point // position where the player enters from above
+	Exit     Point // position of the stairs leading down
+}
+
+// SpawnTable defines which monsters can be found starting at a given depth.
+type SpawnTable struct {
+	MinDepth int           // minimum depth at which this table is used
+	Monsters []*EntityType // monsters that can spawn
+}
+
+// DefaultSpawnTables are the default spawn tables sorted by depth.
+// The deepest table with a MinDepth <= the current depth is used.
+var DefaultSpawnTables = []*SpawnTable{
+	{
+		MinDepth: 0,
+		Monsters: []*EntityType{EntityGoblin, EntityGoblin, EntityOrc},
+	},
+	{
+		MinDepth: 2,
+		Monsters: []*EntityType{EntityGoblin, EntityOrc, EntityOrc, EntityTroll},
+	},
+	{
+		MinDepth: 4,
+		Monsters: []*EntityType{EntityOrc, EntityTroll, EntityTroll},
+	},
+}
+
+// spawnTableForDepth returns the spawn table for the given depth.
+func spawnTableForDepth(tables []*SpawnTable, depth int) *SpawnTable {
+	var best *SpawnTable
+	for _, t := range tables {
+		if t.MinDepth <= depth && (best == nil || t.MinDepth > best.MinDepth) {
+			best = t
+		}
+	}
+	return best
+}
+
+// newLevel generates a new level at the given depth.
+func (g *Game) newLevel(depth int) *Level {
+	// Each level gets its own seed, so that the dungeon is the same
+	// for a given seed, no matter in which order the levels are visited.
+	seed := g.seed + int64(depth)*7919
+	rng := rand.New(rand.NewSource(seed))
+	w := g.generator(g.width, g.height, seed)
+	l := &Level{
+		World: w,
+		Depth: depth,
+	}
+
+	// Find the entrance, which is in the first room (or on a random floor
+	// tile if the generator doesn't provide rooms).
+	if len(w.Rooms) > 0 {
+		l.Entrance = w.Rooms[0].Center()
+	} else if p, ok := w.RandomFloor(rng); ok {
+		l.Entrance = p
+	}
+
+	// The exit is placed in the room farthest away from the entrance.
+	l.Exit = l.Entrance
+	dm := NewDijkstraMap(w, l.Entrance)
+	best := 0.0
+	for _, r := range w.Rooms {
+		c := r.Center()
+		if v := dm.Get(c.X, c.Y); !math.IsInf(v, 1) && v > best {
+			best = v
+			l.Exit = c
+		}
+	}
+
+	// If there is only a single room, use the farthest reachable tile.
+	if l.Exit == l.Entrance {
+		for i, v := range dm.Values {
+			if !math.IsInf(v, 1) && v > best {
+				best = v
+				l.Exit = Point{X: i % w.Width, Y: i / w.Width}
+			}
+		}
+	}
+
+	// Place the stairs. The top level has no stairs leading up.
+	if depth > 0 {
+		w.Cells[l.Entrance.Y][l.Entrance.X] = TileStairsUp
+	}
+	if l.Exit != l.Entrance {
+		w.Cells[l.Exit.Y][l.Exit.X] = TileStairsDown
+	}
+
+	// Populate the rooms (except for the room with the entrance).
+	st := spawnTableForDepth(g.SpawnTables, depth)
+	lt := lootTableForDepth(g.LootTables, depth)
+	for _, r := range w.Rooms {
+		if r.Contains(l.Entrance.X, l.Entrance.Y) {
+			continue
+		}
+		// There is a chance that a creature is placed randomly in
+		// the room, which increases with depth.
+		if st != nil && len(st.Monsters) > 0 && rng.Intn(100) < 50+depth*10 {
+			x, y := randInt(rng, r.X, r.X+r.W), randInt(rng, r.Y, r.Y+r.H)
+			if w.CanMoveTo(x, y) {
+				w.Entities = append(w.Entities, NewEntity(x, y, st.Monsters[rng.Intn(len(st.Monsters))]))
+			}
+		}
+
+		// There is also a chance that an item is lying around.
+		if lt != nil && rng.Intn(100) < 30 {
+			x, y := randInt(rng, r.X, r.X+r.W), randInt(rng, r.Y, r.Y+r.H)
+			if it := lt.Generate(rng, depth); it != nil && w.CanMoveTo(x, y) {
+				it.X, it.Y = x, y
+				w.Items = append(w.Items, it)
+			}
+		}
+	}
+
+	// Set up the FOV.
+	// We can see lit areas up to 20 tiles away, and we carry a
+	// torch that lights up everything within 10 tiles.
+	l.FOV = NewFOV(w, 20)
+	l.FOV.LightRadius = 10
+	return l
+}
+
+// changeLevel moves the player to the level at the given depth, generating
+// it if we haven't been there before. If we descend, the player is placed
+// at the stairs leading up, otherwise at the stairs leading down.
+func (g *Game) changeLevel(depth int) {
+	if depth < 0 {
+		return
+	}
+	for len(g.Levels) <= depth {
+		g.Levels = append(g.Levels, g.newLevel(len(g.Levels)))
+	}
+	descending := depth >= g.Depth
+	g.Depth = depth
+	l := g.Levels[depth]
+	g.World = l.World
+	g.FOV = l.FOV
+	if descending {
+		g.player.X, g.player.Y = l.Entrance.X, l.Entrance.Y
+	} else {
+		g.player.X, g.player.Y = l.Exit.X, l.Exit.Y
+	}
+	g.resetAIMaps()
+	g.FOV.Update(g.player.X, g.player.Y)
+}
+
+// Level returns the current level.
+func (g *Game) Level() *Level {
+	return g.Levels[g.Depth]
+}
+
+// Descend moves the player down if they are standing on stairs leading down.
+func (g *Game) Descend() bool {
+	if g.Cells[g.player.Y][g.player.X] != TileStairsDown {
+		return false
+	}
+	g.changeLevel(g.Depth + 1)
+	g.AddMessage("You descend the stairs")
+	return true
+}
+
+// Ascend moves the player up if they are standing on stairs leading up.
+func (g *Game) Ascend() bool {
+	if g.Cells[g.player.Y][g.player.X] != TileStairsUp {
+		return false
+	}
+	g.changeLevel(g.Depth - 1)
+	g.AddMessage("You ascend the stairs")
+	return true
+}
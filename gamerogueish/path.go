@@ -0,0 +1,237 @@
+package gamerogueish
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Point represents a position in the world.
+type Point struct {
+	X, Y int
+}
+
+// neighbors8 contains the offsets to all 8 neighboring tiles.
+var neighbors8 = [8]Point{
+	{X: 0, Y: -1}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: -1, Y: 0},
+	{X: 1, Y: -1}, {X: 1, Y: 1}, {X: -1, Y: 1}, {X: -1, Y: -1},
+}
+
+// chebyshevDist returns the number of steps between two positions if
+// diagonal movement is allowed.
+func chebyshevDist(x1, y1, x2, y2 int) int {
+	dx := abs(x2 - x1)
+	dy := abs(y2 - y1)
+	if dx > dy {
+		return dx
+	}
+	return dy
+}
+
+// abs returns the absolute value of the given integer.
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// pathNode is a node in the priority queue used by A* and Dijkstra.
+type pathNode struct {
+	idx   int     // index of the tile (y*width + x)
+	prio  float64 // priority of the node (lower is better)
+	order int     // insertion order for deterministic tie breaking
+}
+
+// pathQueue implements heap.Interface for path nodes.
+type pathQueue []pathNode
+
+func (q pathQueue) Len() int { return len(q) }
+
+func (q pathQueue) Less(i, j int) bool {
+	if q[i].prio != q[j].prio {
+		return q[i].prio < q[j].prio
+	}
+	return q[i].order < q[j].order
+}
+
+func (q pathQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathNode)) }
+
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
+
+// FindPath returns the shortest path from the start to the target using
+// A* with diagonal movement. The path excludes the start and includes the
+// target. If blocked is set, it is used to exclude additional tiles (like
+// tiles occupied by other creatures). The target is always considered
+// enterable. Returns nil if no path exists.
+func (w *World) FindPath(sx, sy, tx, ty int, blocked func(x, y int) bool) []Point {
+	if !w.InBounds(sx, sy) || !w.InBounds(tx, ty) || (sx == tx && sy == ty) {
+		return nil
+	}
+	start := sy*w.Width + sx
+	target := ty*w.Width + tx
+
+	cost := make(map[int]int)
+	from := make(map[int]int)
+	cost[start] = 0
+
+	var order int
+	q := &pathQueue{{idx: start, prio: float64(chebyshevDist(sx, sy, tx, ty))}}
+	for q.Len() > 0 {
+		cur := heap.Pop(q).(pathNode).idx
+		if cur == target {
+			// Reconstruct the path.
+			var path []Point
+			for n := cur; n != start; n = from[n] {
+				path = append(path, Point{X: n % w.Width, Y: n / w.Width})
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path
+		}
+		cx, cy := cur%w.Width, cur/w.Width
+		for _, nb := range neighbors8 {
+			nx, ny := cx+nb.X, cy+nb.Y
+			if !w.InBounds(nx, ny) {
+				continue
+			}
+			next := ny*w.Width + nx
+			if next != target && (!w.CanMoveTo(nx, ny) || (blocked != nil && blocked(nx, ny))) {
+				continue
+			}
+			newCost := cost[cur] + 1
+			if c, ok := cost[next]; ok && c <= newCost {
+				continue
+			}
+			cost[next] = newCost
+			from[next] = cur
+			order++
+			heap.Push(q, pathNode{
+				idx:   next,
+				prio:  float64(newCost + chebyshevDist(nx, ny, tx, ty)),
+				order: order,
+			})
+		}
+	}
+	return nil
+}
+
+// DijkstraMap contains for each tile the distance (in steps) to the
+// closest goal. Monsters can move towards the goals by rolling downhill.
+// See: http://www.roguebasin.com/index.php/The_Incredible_Power_of_Dijkstra_Maps
+type DijkstraMap struct {
+	Width  int
+	Height int
+	Values []float64 // distance values (row major), +Inf if unreachable
+	w      *World    // world the map was computed for
+}
+
+// NewDijkstraMap returns a new Dijkstra map with the given goals.
+func NewDijkstraMap(w *World, goals ...Point) *DijkstraMap {
+	m := newEmptyDijkstraMap(w)
+	for _, g := range goals {
+		if w.InBounds(g.X, g.Y) {
+			m.Values[g.Y*w.Width+g.X] = 0
+		}
+	}
+	m.scan()
+	return m
+}
+
+// newEmptyDijkstraMap returns a new Dijkstra map with all tiles set
+// to unreachable.
+func newEmptyDijkstraMap(w *World) *DijkstraMap {
+	m := &DijkstraMap{
+		Width:  w.Width,
+		Height: w.Height,
+		Values: make([]float64, w.Width*w.Height),
+		w:      w,
+	}
+	for i := range m.Values {
+		m.Values[i] = math.Inf(1)
+	}
+	return m
+}
+
+// scan propagates the current values to all reachable tiles, so that no
+// tile has a value larger than its lowest neighbor + 1.
+func (m *DijkstraMap) scan() {
+	var order int
+	q := &pathQueue{}
+	for i, v := range m.Values {
+		if !math.IsInf(v, 1) {
+			order++
+			*q = append(*q, pathNode{idx: i, prio: v, order: order})
+		}
+	}
+	heap.Init(q)
+	for q.Len() > 0 {
+		n := heap.Pop(q).(pathNode)
+		if n.prio > m.Values[n.idx] {
+			continue // Outdated entry.
+		}
+		cx, cy := n.idx%m.Width, n.idx/m.Width
+		for _, nb := range neighbors8 {
+			nx, ny := cx+nb.X, cy+nb.Y
+			if !m.w.CanMoveTo(nx, ny) {
+				continue
+			}
+			next := ny*m.Width + nx
+			if v := n.prio + 1; v < m.Values[next] {
+				m.Values[next] = v
+				order++
+				heap.Push(q, pathNode{idx: next, prio: v, order: order})
+			}
+		}
+	}
+}
+
+// Get returns the value of the given tile (+Inf if out of bounds or
+// unreachable).
+func (m *DijkstraMap) Get(x, y int) float64 {
+	if x < 0 || x >= m.Width || y < 0 || y >= m.Height {
+		return math.Inf(1)
+	}
+	return m.Values[y*m.Width+x]
+}
+
+// FleeMap returns a new Dijkstra map that leads away from the goals of
+// the current map. Instead of running into the nearest corner, creatures
+// following this map will try to get past the goals if that leads to a
+// safer spot.
+func (m *DijkstraMap) FleeMap() *DijkstraMap {
+	const fleeFactor = -1.2
+	f := newEmptyDijkstraMap(m.w)
+	for i, v := range m.Values {
+		if !math.IsInf(v, 1) {
+			f.Values[i] = v * fleeFactor
+		}
+	}
+	f.scan()
+	return f
+}
+
+// Next returns the neighboring tile with the lowest value that is lower
+// than the value of the given tile. If blocked is set, it is used to
+// exclude tiles (like tiles occupied by other creatures).
+func (m *DijkstraMap) Next(x, y int, blocked func(x, y int) bool) (Point, bool) {
+	best := m.Get(x, y)
+	var bestPoint Point
+	var found bool
+	for _, nb := range neighbors8 {
+		nx, ny := x+nb.X, y+nb.Y
+		if v := m.Get(nx, ny); v < best && (blocked == nil || !blocked(nx, ny)) {
+			best = v
+			bestPoint = Point{X: nx, Y: ny}
+			found = true
+		}
+	}
+	return bestPoint, found
+}
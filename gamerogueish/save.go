@@ -0,0 +1,393 @@
+package gamerogueish
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+
+	"github.com/Flokey82/go_gens/utils"
+)
+
+// SaveGame contains the complete state of a game, which can be stored as
+// JSON and restored using NewGameFromSave.
+type SaveGame struct {
+	Width        int         // width of the generated levels
+	Height       int         // height of the generated levels
+	Seed         int64       // seed of the game
+	RandState    uint64      // state of the game RNG
+	Depth        int         // current depth
+	Turns        int         // number of turns taken by the player
+	CurrentActor int         // index of the entity whose turn it is
+	Player       *EntitySave // player entity
+	Levels       []*LevelSave
+	Messages     []string
+	Commands     []Command // all commands issued so far (for replays)
+}
+
+// LevelSave contains the state of a level.
+type LevelSave struct {
+	Depth    int
+	Entrance Point
+	Exit     Point
+	Cells    []string // tiles (one string per row)
+	Seen     []string // FOV memory (one string per row, '1' if seen)
+	Lights   []*Light
+	Rooms    []*Room
+	Entities []*EntitySave
+	Items    []*ItemSave // items lying on the ground
+}
+
+// EntitySave contains the state of an entity.
+type EntitySave struct {
+	Type          string // name of the entity type
+	X             int
+	Y             int
+	Health        int
+	Energy        int
+	LastSeen      Point
+	LastSeenUntil int
+	Items         []*ItemSave // inventory
+	Selected      int         // selected inventory index
+}
+
+// ItemSave contains the state of an item.
+type ItemSave struct {
+	Type       string // name of the item type
+	X          int
+	Y          int
+	Equipped   bool
+	Rarity     Rarity
+	Prefix     string `json:",omitempty"` // name of the prefix (if any)
+	Suffix     string `json:",omitempty"` // name of the suffix (if any)
+	Identified bool
+}
+
+// SaveGame returns the current state of the game.
+func (g *Game) SaveGame() *SaveGame {
+	s := &SaveGame{
+		Width:        g.width,
+		Height:       g.height,
+		Seed:         g.seed,
+		RandState:    g.src.State,
+		Depth:        g.Depth,
+		Turns:        g.Turns,
+		CurrentActor: g.currentActor,
+		Player:       saveEntity(g.player),
+		Messages:     append([]string(nil), g.Messages...),
+		Commands:     append([]Command(nil), g.commands...),
+	}
+	for _, l := range g.Levels {
+		ls := &LevelSave{
+			Depth:    l.Depth,
+			Entrance: l.Entrance,
+			Exit:     l.Exit,
+			Lights:   l.Lights,
+			Rooms:    l.Rooms,
+		}
+		for y := range l.Cells {
+			ls.Cells = append(ls.Cells, string(l.Cells[y]))
+			seen := make([]byte, len(l.FOV.Seen[y]))
+			for x, v := range l.FOV.Seen[y] {
+				seen[x] = '0'
+				if v {
+					seen[x] = '1'
+				}
+			}
+			ls.Seen = append(ls.Seen, string(seen))
+		}
+		for _, e := range l.Entities {
+			ls.Entities = append(ls.Entities, saveEntity(e))
+		}
+		for _, it := range l.Items {
+			ls.Items = append(ls.Items, saveItem(it))
+		}
+		s.Levels = append(s.Levels, ls)
+	}
+	return s
+}
+
+// saveEntity returns the state of the given entity.
+func saveEntity(e *Entity) *EntitySave {
+	es := &EntitySave{
+		Type:          e.EntityType.Name,
+		X:             e.X,
+		Y:             e.Y,
+		Health:        e.Health,
+		Energy:        e.Energy,
+		LastSeen:      e.LastSeen,
+		LastSeenUntil: e.LastSeenUntil,
+		Selected:      e.selectedItem,
+	}
+	for _, it := range e.Items {
+		es.Items = append(es.Items, saveItem(it))
+	}
+	return es
+}
+
+// saveItem returns the state of the given item.
+func saveItem(it *Item) *ItemSave {
+	is := &ItemSave{
+		Type:       it.ItemType.Name,
+		X:          it.X,
+		Y:          it.Y,
+		Equipped:   it.Equipped,
+		Rarity:     it.Rarity,
+		Identified: it.Identified,
+	}
+	if it.Prefix != nil {
+		is.Prefix = it.Prefix.Name
+	}
+	if it.Suffix != nil {
+		is.Suffix = it.Suffix.Name
+	}
+	return is
+}
+
+// NewGameFromSave restores a game from the given state. The world generator
+// is used to generate levels that haven't been visited yet, so it has to be
+// the same one the game was started with.
+func NewGameFromSave(s *SaveGame, gw GenWorld) (*Game, error) {
+	if len(s.Levels) == 0 || s.Depth < 0 || s.Depth >= len(s.Levels) {
+		return nil, fmt.Errorf("invalid depth %d (%d levels)", s.Depth, len(s.Levels))
+	}
+	g := &Game{
+		generator:    gw,
+		width:        s.Width,
+		height:       s.Height,
+		seed:         s.Seed,
+		currentActor: s.CurrentActor,
+		commands:     append([]Command(nil), s.Commands...),
+		src:          utils.NewXorshiftSource(s.Seed),
+		Depth:        s.Depth,
+		Turns:        s.Turns,
+		SpawnTables:  DefaultSpawnTables,
+		LootTables:   DefaultLootTables,
+		Messages:     append([]string(nil), s.Messages...),
+	}
+	g.rng = rand.New(g.src)
+
+	var err error
+	if g.player, err = loadEntity(s.Player); err != nil {
+		return nil, err
+	}
+	for _, ls := range s.Levels {
+		l, err := loadLevel(ls)
+		if err != nil {
+			return nil, err
+		}
+		g.Levels = append(g.Levels, l)
+	}
+
+	// Enter the current level.
+	l := g.Levels[g.Depth]
+	g.World = l.World
+	g.FOV = l.FOV
+	g.FOV.Update(g.player.X, g.player.Y)
+
+	// Restore the RNG state last.
+	g.src.State = s.RandState
+	return g, nil
+}
+
+// loadLevel restores a level from the given state.
+func loadLevel(ls *LevelSave) (*Level, error) {
+	if len(ls.Cells) == 0 || len(ls.Seen) != len(ls.Cells) {
+		return nil, fmt.Errorf("invalid level %d", ls.Depth)
+	}
+	w := NewWorld(len(ls.Cells[0]), len(ls.Cells))
+	for y, row := range ls.Cells {
+		if len(row) != w.Width || len(ls.Seen[y]) != w.Width {
+			return nil, fmt.Errorf("invalid row %d in level %d", y, ls.Depth)
+		}
+		copy(w.Cells[y], row)
+	}
+	w.Lights = ls.Lights
+	w.Rooms = ls.Rooms
+	for _, es := range ls.Entities {
+		e, err := loadEntity(es)
+		if err != nil {
+			return nil, err
+		}
+		w.Entities = append(w.Entities, e)
+	}
+	for _, is := range ls.Items {
+		it, err := loadItem(is)
+		if err != nil {
+			return nil, err
+		}
+		w.Items = append(w.Items, it)
+	}
+
+	l := &Level{
+		World:    w,
+		Depth:    ls.Depth,
+		Entrance: ls.Entrance,
+		Exit:     ls.Exit,
+	}
+	l.FOV = NewFOV(w, 20)
+	l.FOV.LightRadius = 10
+	for y, row := range ls.Seen {
+		for x := range row {
+			l.FOV.Seen[y][x] = row[x] == '1'
+		}
+	}
+	return l, nil
+}
+
+// loadEntity restores an entity from the given state.
+func loadEntity(es *EntitySave) (*Entity, error) {
+	if es == nil {
+		return nil, fmt.Errorf("missing entity")
+	}
+	var et *EntityType
+	for _, t := range append([]*EntityType{EntityPlayer}, MonsterEntities...) {
+		if t.Name == es.Type {
+			et = t
+			break
+		}
+	}
+	if et == nil {
+		return nil, fmt.Errorf("unknown entity type %q", es.Type)
+	}
+	e := NewEntity(es.X, es.Y, et)
+	e.Health = es.Health
+	e.Energy = es.Energy
+	e.LastSeen = es.LastSeen
+	e.LastSeenUntil = es.LastSeenUntil
+	for _, is := range es.Items {
+		it, err := loadItem(is)
+		if err != nil {
+			return nil, err
+		}
+		e.Items = append(e.Items, it)
+		if it.Equipped {
+			e.Slots[it.Type] = it
+		}
+	}
+	e.selectedItem = es.Selected
+	return e, nil
+}
+
+// loadItem restores an item from the given state.
+func loadItem(is *ItemSave) (*Item, error) {
+	var it *Item
+	for _, t := range ItemTypes {
+		if t.Name == is.Type {
+			it = t.New()
+			break
+		}
+	}
+	if it == nil {
+		return nil, fmt.Errorf("unknown item type %q", is.Type)
+	}
+	it.X, it.Y = is.X, is.Y
+	it.Equipped = is.Equipped
+	it.Rarity = is.Rarity
+	it.Identified = is.Identified
+	var err error
+	if it.Prefix, err = findAffix(is.Prefix); err != nil {
+		return nil, err
+	}
+	if it.Suffix, err = findAffix(is.Suffix); err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+// findAffix returns the affix with the given name (nil if the name is empty).
+func findAffix(name string) (*Affix, error) {
+	if name == "" {
+		return nil, nil
+	}
+	for _, a := range Affixes {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown affix %q", name)
+}
+
+// Save stores the game as JSON under the given path.
+func (g *Game) Save(path string) error {
+	return writeJSON(path, g.SaveGame())
+}
+
+// LoadGame restores a game from the savegame stored under the given path
+// (see NewGameFromSave).
+func LoadGame(path string, gw GenWorld) (*Game, error) {
+	var s SaveGame
+	if err := readJSON(path, &s); err != nil {
+		return nil, err
+	}
+	return NewGameFromSave(&s, gw)
+}
+
+// Replay contains everything needed to reproduce a game: the parameters
+// passed to NewGame and all commands issued by the player.
+type Replay struct {
+	Width    int
+	Height   int
+	Seed     int64
+	Commands []Command
+}
+
+// Replay returns the replay of the game so far.
+func (g *Game) Replay() *Replay {
+	return &Replay{
+		Width:    g.width,
+		Height:   g.height,
+		Seed:     g.seed,
+		Commands: append([]Command(nil), g.commands...),
+	}
+}
+
+// Play starts a new game with the given world generator and performs all
+// recorded commands. Since all randomness goes through the seeded game RNG,
+// the resulting game is identical to the recorded one.
+//
+// NOTE: The world generator has to be the same one used for the recording.
+func (r *Replay) Play(gw GenWorld) *Game {
+	g := NewGame(gw, r.Width, r.Height, r.Seed)
+	for _, cmd := range r.Commands {
+		g.Do(cmd)
+	}
+	return g
+}
+
+// SaveReplay stores the replay of the game as JSON under the given path.
+func (g *Game) SaveReplay(path string) error {
+	return writeJSON(path, g.Replay())
+}
+
+// LoadReplay reads the replay stored under the given path.
+func LoadReplay(path string) (*Replay, error) {
+	var r Replay
+	if err := readJSON(path, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// writeJSON encodes v as JSON and writes it to the given path.
+func writeJSON(path string, v interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(v); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readJSON decodes the JSON stored under the given path into v.
+func readJSON(path string, v interface{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewDecoder(f).Decode(v)
+}
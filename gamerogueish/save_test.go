@@ -0,0 +1,99 @@
+package gamerogueish
+
+import (
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+// testCommands returns a random but reproducible sequence of commands.
+func testCommands(n int) []Command {
+	rng := rand.New(rand.NewSource(42))
+	dirs := [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}}
+	cmds := make([]Command, n)
+	for i := range cmds {
+		switch r := rng.Intn(20); {
+		case r == 0:
+			cmds[i] = Command{Type: CmdDebugAddPotion}
+		case r == 1:
+			cmds[i] = Command{Type: CmdUseItem, Index: rng.Intn(4)}
+		case r == 2:
+			cmds[i] = Command{Type: CmdPickUp}
+		case r == 3:
+			cmds[i] = Command{Type: CmdDescend}
+		case r < 6:
+			cmds[i] = Command{Type: CmdAttack}
+		default:
+			d := dirs[rng.Intn(len(dirs))]
+			cmds[i] = Command{Type: CmdMove, DX: d[0], DY: d[1]}
+		}
+	}
+	return cmds
+}
+
+func TestSaveReplay(t *testing.T) {
+	const width, height, seed = 60, 40, 4321
+	cmds := testCommands(120)
+	half := len(cmds) / 2
+
+	// Play the first half and save the game.
+	g := NewGame(GenWorldSimpleDungeon, width, height, seed)
+	for _, cmd := range cmds[:half] {
+		g.Do(cmd)
+	}
+	if g.IsGameOver() {
+		t.Fatalf("player died before saving (turn %d)", g.Turns)
+	}
+	path := filepath.Join(t.TempDir(), "save.json")
+	if err := g.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Load the game and play the second half.
+	loaded, err := LoadGame(path, GenWorldSimpleDungeon)
+	if err != nil {
+		t.Fatalf("LoadGame: %v", err)
+	}
+	for _, cmd := range cmds[half:] {
+		loaded.Do(cmd)
+	}
+
+	// Replay all commands in a new game.
+	r := &Replay{Width: width, Height: height, Seed: seed, Commands: cmds}
+	replayed := r.Play(GenWorldSimpleDungeon)
+
+	if loaded.Turns != replayed.Turns {
+		t.Errorf("turns: got %d, want %d", loaded.Turns, replayed.Turns)
+	}
+	if loaded.src.State != replayed.src.State {
+		t.Errorf("rng state: got %d, want %d", loaded.src.State, replayed.src.State)
+	}
+	if loaded.Depth != replayed.Depth || len(loaded.Levels) != len(replayed.Levels) {
+		t.Fatalf("depth: got %d (%d levels), want %d (%d levels)", loaded.Depth, len(loaded.Levels), replayed.Depth, len(replayed.Levels))
+	}
+	lp, rp := loaded.Player(), replayed.Player()
+	if lp.X != rp.X || lp.Y != rp.Y || lp.Health != rp.Health {
+		t.Errorf("player: got (%d, %d) hp %d, want (%d, %d) hp %d", lp.X, lp.Y, lp.Health, rp.X, rp.Y, rp.Health)
+	}
+	if len(lp.Inventory.Items) != len(rp.Inventory.Items) {
+		t.Errorf("inventory: got %d items, want %d", len(lp.Inventory.Items), len(rp.Inventory.Items))
+	}
+	for i, l := range loaded.Levels {
+		rl := replayed.Levels[i]
+		for y := range l.Cells {
+			if string(l.Cells[y]) != string(rl.Cells[y]) {
+				t.Errorf("level %d row %d: got %q, want %q", i, y, l.Cells[y], rl.Cells[y])
+			}
+		}
+		if len(l.Entities) != len(rl.Entities) {
+			t.Errorf("level %d: got %d entities, want %d", i, len(l.Entities), len(rl.Entities))
+			continue
+		}
+		for j, e := range l.Entities {
+			re := rl.Entities[j]
+			if e.X != re.X || e.Y != re.Y || e.Health != re.Health {
+				t.Errorf("level %d entity %d: got (%d, %d) hp %d, want (%d, %d) hp %d", i, j, e.X, e.Y, e.Health, re.X, re.Y, re.Health)
+			}
+		}
+	}
+}
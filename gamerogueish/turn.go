@@ -0,0 +1,153 @@
+package gamerogueish
+
+// EnergyActionCost is the amount of energy an entity needs to perform an
+// action. Entities gain energy depending on their speed each tick, so
+// faster entities can act more often.
+const EnergyActionCost = 100
+
+// SpeedNormal is the default speed of an entity.
+const SpeedNormal = 10
+
+// CommandType is the type of a player command.
+type CommandType int
+
+// Player commands.
+const (
+	CmdWait CommandType = iota
+	CmdMove
+	CmdAttack
+	CmdUseItem
+	CmdPickUp
+	CmdDescend
+	CmdAscend
+	CmdDebugAddPotion // adds a potion to the inventory (for dev purposes)
+)
+
+// Command is an input of the player. Commands are independent of the UI,
+// so the game can be driven by a bot, a test, or a replay.
+type Command struct {
+	Type  CommandType
+	DX    int // x direction (CmdMove)
+	DY    int // y direction (CmdMove)
+	Index int // inventory index (CmdUseItem)
+}
+
+// Action returns the action corresponding to the command.
+func (c Command) Action() Action {
+	switch c.Type {
+	case CmdMove:
+		return &MoveAction{DX: c.DX, DY: c.DY}
+	case CmdAttack:
+		return &AttackAdjacentAction{}
+	case CmdUseItem:
+		return &UseItemAction{Index: c.Index}
+	case CmdPickUp:
+		return &PickUpAction{}
+	case CmdDescend:
+		return &StairsAction{Down: true}
+	case CmdAscend:
+		return &StairsAction{Down: false}
+	default:
+		return &WaitAction{}
+	}
+}
+
+// Do performs the given command as the next action of the player and runs
+// the game until the player has to decide on the next action.
+// Returns false if the action failed (in which case no time has passed).
+func (g *Game) Do(cmd Command) bool {
+	if g.IsGameOver() {
+		return false
+	}
+	turns := g.Turns
+	g.commands = append(g.commands, cmd)
+	if cmd.Type == CmdDebugAddPotion {
+		// NOTE: This is a cheat, so no time passes.
+		g.player.Inventory.Items = append(g.player.Inventory.Items, ItemTypePotion.New())
+		return true
+	}
+	g.playerAction = cmd.Action()
+	g.process()
+	return g.Turns > turns
+}
+
+// IsGameOver returns true if the player has died.
+func (g *Game) IsGameOver() bool {
+	return g.player.IsDead()
+}
+
+// Player returns the player entity.
+func (g *Game) Player() *Entity {
+	return g.player
+}
+
+// actors returns all entities that take turns on the current level.
+func (g *Game) actors() []*Entity {
+	return append([]*Entity{g.player}, g.Entities...)
+}
+
+// process runs the game loop until the player has to decide on an action
+// or the player is dead.
+//
+// Each entity gains energy depending on its speed every time it is its turn.
+// Once it has enough energy, it performs an action which consumes the energy.
+func (g *Game) process() {
+	for !g.IsGameOver() {
+		actors := g.actors()
+		if g.currentActor >= len(actors) {
+			g.currentActor = 0
+		}
+		e := actors[g.currentActor]
+		if e.IsDead() {
+			g.currentActor++
+			continue
+		}
+		if e.Energy < EnergyActionCost {
+			e.Energy += e.speed()
+			g.currentActor++
+			continue
+		}
+
+		if e == g.player {
+			// Wait for the player to decide.
+			if g.playerAction == nil {
+				return
+			}
+			a := g.playerAction
+			g.playerAction = nil
+			if !g.perform(e, a) {
+				// The action failed, so let the player try something else.
+				return
+			}
+			g.Turns++
+
+			// The player has acted, so update the FOV and the AI maps.
+			g.Compute(g.player.X, g.player.Y)
+			g.resetAIMaps()
+		} else if !g.perform(e, g.decideAction(e)) {
+			// If a creature can't perform its action, it waits.
+			g.perform(e, &WaitAction{})
+		}
+
+		// NOTE: If the player changed the level, the actors are now the
+		// creatures of the new level.
+		g.currentActor++
+	}
+}
+
+// perform performs the given action (or its alternates) and consumes the
+// energy of the entity if it succeeded.
+func (g *Game) perform(e *Entity, a Action) bool {
+	for a != nil {
+		res := a.Perform(g, e)
+		if res.Alternate != nil {
+			a = res.Alternate
+			continue
+		}
+		if res.Succeeded {
+			e.Energy -= EnergyActionCost
+		}
+		return res.Succeeded
+	}
+	return false
+}
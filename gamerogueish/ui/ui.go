@@ -0,0 +1,268 @@
+// Package ui renders a gamerogueish game in a console window using ramen and
+// translates the keyboard input to game commands.
+package ui
+
+import (
+	"fmt"
+
+	"github.com/BigJk/ramen/concolor"
+	"github.com/BigJk/ramen/console"
+	"github.com/BigJk/ramen/font"
+	"github.com/BigJk/ramen/t"
+	"github.com/Flokey82/go_gens/gamerogueish"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+const (
+	labelWindow     = "rogue-ish"
+	labelWorldView  = "World View"
+	labelPlayerInfo = "Player Info"
+	saveFile        = "rogueish.sav"
+)
+
+// UI renders the game using ramen and translates the keyboard input to
+// game commands.
+type UI struct {
+	*gamerogueish.Game                  // game to render
+	rootView           *console.Console // view for all sub views
+	worldView          *console.Console // contains map
+	playerInfoView     *console.Console // contains player info
+	messageView        *console.Console // contains messages
+}
+
+// New returns a new UI for the given game.
+func New(g *gamerogueish.Game) (*UI, error) {
+	ui := &UI{Game: g}
+
+	// Init views / UI.
+	rootView, err := console.New(60, 35, font.DefaultFont, labelWindow)
+	if err != nil {
+		return nil, err
+	}
+	ui.rootView = rootView
+
+	worldView, err := rootView.CreateSubConsole(0, 1, rootView.Width-20, rootView.Height-4)
+	if err != nil {
+		return nil, err
+	}
+	ui.worldView = worldView
+
+	playerInfoView, err := rootView.CreateSubConsole(worldView.Width, 1, 20, rootView.Height-4)
+	if err != nil {
+		return nil, err
+	}
+	ui.playerInfoView = playerInfoView
+
+	messageView, err := rootView.CreateSubConsole(0, rootView.Height-3, rootView.Width, 3)
+	if err != nil {
+		return nil, err
+	}
+	ui.messageView = messageView
+
+	return ui, nil
+}
+
+// Start opens the window and runs the game.
+func (ui *UI) Start() {
+	// Setup input.
+	ui.rootView.SetTickHook(ui.HandleInput)
+
+	// Set up renderer.
+	ui.rootView.SetPreRenderHook(ui.Update)
+
+	// Start!
+	ui.rootView.Start(2)
+}
+
+// HandleInput translates the keyboard input to a command and passes it to
+// the game.
+func (ui *UI) HandleInput(timeElapsed float64) error {
+	if cmd, ok := ui.readCommand(); ok {
+		ui.Do(cmd)
+	}
+
+	// Inventory stuff.
+	// TODO: Move this to a UI component.
+	inv := &ui.Player().Inventory
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+		inv.SelectItem(inv.SelectedIndex() - 1)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+		inv.SelectItem(inv.SelectedIndex() + 1)
+	}
+
+	// Quick save and load.
+	if inpututil.IsKeyJustPressed(ebiten.KeyF5) {
+		if err := ui.Save(saveFile); err != nil {
+			ui.AddMessage(fmt.Sprintf("Saving failed: %v", err))
+		} else {
+			ui.AddMessage("Game saved")
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF9) {
+		g, err := gamerogueish.LoadGame(saveFile, ui.Generator())
+		if err != nil {
+			ui.AddMessage(fmt.Sprintf("Loading failed: %v", err))
+		} else {
+			ui.Game = g
+			ui.AddMessage("Game loaded")
+		}
+	}
+	return nil
+}
+
+// readCommand returns the command corresponding to the pressed key (if any).
+func (ui *UI) readCommand() (gamerogueish.Command, bool) {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyW):
+		return gamerogueish.Command{Type: gamerogueish.CmdMove, DY: -1}, true
+	case inpututil.IsKeyJustPressed(ebiten.KeyS):
+		return gamerogueish.Command{Type: gamerogueish.CmdMove, DY: 1}, true
+	case inpututil.IsKeyJustPressed(ebiten.KeyA):
+		return gamerogueish.Command{Type: gamerogueish.CmdMove, DX: -1}, true
+	case inpututil.IsKeyJustPressed(ebiten.KeyD):
+		return gamerogueish.Command{Type: gamerogueish.CmdMove, DX: 1}, true
+	case inpututil.IsKeyJustPressed(ebiten.KeySpace):
+		return gamerogueish.Command{Type: gamerogueish.CmdAttack}, true
+	case inpututil.IsKeyJustPressed(ebiten.KeyPeriod):
+		return gamerogueish.Command{Type: gamerogueish.CmdDescend}, true
+	case inpututil.IsKeyJustPressed(ebiten.KeyComma):
+		return gamerogueish.Command{Type: gamerogueish.CmdAscend}, true
+	case inpututil.IsKeyJustPressed(ebiten.KeyG):
+		return gamerogueish.Command{Type: gamerogueish.CmdPickUp}, true
+	case inpututil.IsKeyJustPressed(ebiten.KeyZ):
+		return gamerogueish.Command{Type: gamerogueish.CmdWait}, true
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		return gamerogueish.Command{Type: gamerogueish.CmdUseItem, Index: ui.Player().Inventory.SelectedIndex()}, true
+	case inpututil.IsKeyJustPressed(ebiten.KeyP):
+		// For dev purposes we add a key to add potions.
+		return gamerogueish.Command{Type: gamerogueish.CmdDebugAddPotion}, true
+	}
+	return gamerogueish.Command{}, false
+}
+
+var (
+	colGrey    = concolor.RGB(128, 128, 128)
+	colDarkRed = concolor.RGB(128, 0, 0)
+	colItem    = concolor.RGB(200, 200, 0)
+)
+
+// Update renders the game.
+func (ui *UI) Update(screen *ebiten.Image, timeDelta float64) error {
+	// Clear console.
+	ui.rootView.ClearAll()
+	ui.rootView.TransformAll(t.Background(concolor.RGB(50, 50, 50)))
+
+	ui.worldView.ClearAll()
+	ui.worldView.TransformAll(t.Background(concolor.RGB(55, 55, 55)), t.Char(0))
+
+	ui.playerInfoView.ClearAll()
+
+	ui.messageView.ClearAll()
+
+	// Draw header.
+	ui.rootView.TransformArea(0, 0, ui.rootView.Width, 1, t.Background(concolor.RGB(80, 80, 80)))
+	ui.rootView.Print(2, 0, labelWorldView, t.Foreground(concolor.White))
+	ui.rootView.Print(ui.worldView.Width+2, 0, labelPlayerInfo, t.Foreground(concolor.White))
+
+	// Draw world centered around the player.
+	midX := ui.worldView.Width / 2
+	midY := ui.worldView.Height / 2
+
+	// Player position.
+	player := ui.Player()
+	pX := player.X
+	pY := player.Y
+
+	// TODO: Skip drawing everything outside of the view.
+	for y := range ui.Cells {
+		for x, cv := range ui.Cells[y] {
+			// Skip empty cells and cells we haven't seen.
+			if cv == gamerogueish.TileFloor || !ui.Seen[y][x] {
+				continue
+			}
+
+			// Previously seen tiles that we can't see right now are greyed out.
+			if !ui.IsVisible(x, y) {
+				ui.worldView.Transform(midX-pX+x, midY-pY+y, t.CharByte(cv), t.Foreground(colGrey))
+			} else {
+				ui.worldView.Transform(midX-pX+x, midY-pY+y, t.CharByte(cv))
+			}
+		}
+	}
+
+	// Draw items lying on the ground.
+	for _, it := range ui.Items {
+		if !ui.IsVisible(it.X, it.Y) {
+			continue
+		}
+		ui.worldView.Transform(midX-pX+it.X, midY-pY+it.Y, t.CharByte(it.Tile), t.Foreground(colItem))
+	}
+
+	// draw player in the middle
+	ui.worldView.Transform(midX, midY, t.CharByte(player.Tile), t.Foreground(concolor.Green))
+
+	// Draw entities.
+	for _, e := range ui.Entities {
+		// Draw only if we can see the creatures.
+		if !ui.IsVisible(e.X, e.Y) {
+			continue
+		}
+		transformer := t.Foreground(concolor.Red)
+		if e.IsDead() {
+			transformer = t.Foreground(colDarkRed)
+		}
+		ui.worldView.Transform(midX-pX+e.X, midY-pY+e.Y, t.CharByte(e.Tile), transformer)
+	}
+
+	// Draw player info.
+	ui.playerInfoView.PrintBounded(1, 1, ui.playerInfoView.Width-2, 2, fmt.Sprintf("Health: %d/%d", player.Health, player.BaseHealth))
+	ui.playerInfoView.PrintBounded(1, 2, ui.playerInfoView.Width-2, 2, fmt.Sprintf("Def: %d Att: %s", player.DefenseValue(), player.DamageRoll()))
+	ui.playerInfoView.PrintBounded(1, 3, ui.playerInfoView.Width-2, 2, fmt.Sprintf("X=%d Y=%d D=%d", pX, pY, ui.Depth), t.Foreground(colGrey))
+
+	// Draw inventory.
+	//
+	// TODO:
+	// - Move this to a UI component.
+	// - Render equipped armor and weapon.
+	ui.playerInfoView.PrintBounded(1, 4, ui.playerInfoView.Width-2, 2, fmt.Sprintf("Inventory (%d)", player.Inventory.Count()))
+	var idx int
+	for i, item := range player.Items {
+		var entry string
+		if item.Equipped {
+			entry = fmt.Sprintf("%d:*%s", i, item)
+		} else {
+			entry = fmt.Sprintf("%d: %s", i, item)
+		}
+		var transformers []t.Transformer
+		if i == player.Inventory.SelectedIndex() {
+			transformers = append(transformers, t.Foreground(concolor.Green))
+		}
+		ui.playerInfoView.PrintBounded(2, 6+idx, ui.playerInfoView.Width-2, 2, entry, transformers...)
+		idx++
+	}
+
+	// Draw what can be found at or next to the current position.
+	// List entities first.
+	entities := ui.EntitiesInRange(pX, pY)
+
+	ui.playerInfoView.PrintBounded(1, 7+idx, ui.playerInfoView.Width-2, 2, fmt.Sprintf("In Range (%d)", len(entities)))
+	for i, e := range entities {
+		entry := e.Name
+		var transformers []t.Transformer
+		if e.IsDead() {
+			entry += " (dead)"
+			transformers = append(transformers, t.Foreground(concolor.Red))
+		}
+		ui.playerInfoView.PrintBounded(2, 8+idx, ui.playerInfoView.Width-2, 2, fmt.Sprintf("%d: %s", i, entry), transformers...)
+		idx++
+	}
+
+	// List messages.
+	for i, m := range ui.Messages {
+		ui.messageView.PrintBounded(1, i, ui.messageView.Width-2, 2, m)
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package gamerogueish
 
 import (
 	"math/rand"
+
+	"github.com/Flokey82/go_gens/gendungeon"
 )
 
 // World represents a game world.
@@ -10,6 +12,23 @@ type World struct {
 	Width    int       // width of the world in cells
 	Height   int       // height of the world in cells
 	Entities []*Entity // entities in the world (creatures)
+	Lights   []*Light  // light sources in the world
+	Items    []*Item   // items lying on the ground
+	Rooms    []*Room   // rooms in the world (used for placing stairs, creatures and items)
+}
+
+// Special tiles.
+const (
+	TileFloor      = ' '
+	TileWall       = '#'
+	TileStairsUp   = '<'
+	TileStairsDown = '>'
+)
+
+// OpaqueTiles contains all tiles that block the line of sight.
+// All other tiles are transparent (even if they are solid).
+var OpaqueTiles = map[byte]bool{
+	'#': true,
 }
 
 // NewWorld returns a new world with the given width and height.
@@ -25,15 +44,59 @@ func NewWorld(width, height int) *World {
 	return w
 }
 
-// IsSolid checks if a tile is solid (tile content is not a space ' ' character).
+// IsSolid checks if a tile is solid (tile content is not a space ' ' character
+// or stairs).
 func (w *World) IsSolid(x int, y int) bool {
-	return w.Cells[y][x] != ' '
+	c := w.Cells[y][x]
+	return c != TileFloor && c != TileStairsUp && c != TileStairsDown
+}
+
+// IsOpaque returns true if the tile blocks the line of sight.
+// Tiles outside of the world bounds are always opaque.
+func (w *World) IsOpaque(x, y int) bool {
+	return !w.InBounds(x, y) || OpaqueTiles[w.Cells[y][x]]
 }
 
-// CanMoveTo checks if a tile is solid and if it is not occupied by an entity.
+// CanMoveTo checks if a tile is within bounds and not solid.
 func (w *World) CanMoveTo(x, y int) bool {
-	// TODO: Bounds check.
-	return w.Cells[y][x] == ' '
+	return w.InBounds(x, y) && !w.IsSolid(x, y)
+}
+
+// ItemsAt returns all items lying on the ground at the given position.
+func (w *World) ItemsAt(x, y int) []*Item {
+	var items []*Item
+	for _, it := range w.Items {
+		if it.X == x && it.Y == y {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+// RemoveItem removes the given item from the ground.
+func (w *World) RemoveItem(it *Item) {
+	for i, v := range w.Items {
+		if v == it {
+			w.Items = append(w.Items[:i], w.Items[i+1:]...)
+			return
+		}
+	}
+}
+
+// RandomFloor returns a random floor tile using the given rng.
+func (w *World) RandomFloor(rng *rand.Rand) (Point, bool) {
+	var floor []Point
+	for y := range w.Cells {
+		for x := range w.Cells[y] {
+			if w.Cells[y][x] == TileFloor {
+				floor = append(floor, Point{X: x, Y: y})
+			}
+		}
+	}
+	if len(floor) == 0 {
+		return Point{}, false
+	}
+	return floor[rng.Intn(len(floor))], true
 }
 
 // Fill all cells with the given tile.
@@ -96,6 +159,7 @@ func GenWorldSimpleDungeon(width, height int, seed int64) *World {
 
 	// Carve out the starting room.
 	w.CarveRoom(rooms[0])
+	w.Rooms = append(w.Rooms, rooms[0])
 
 	// Place rooms until we run out of attempts or reach the max room count.
 	for i := 0; i < attempts; i++ {
@@ -146,14 +210,16 @@ func GenWorldSimpleDungeon(width, height int, seed int64) *World {
 
 		// Draw room.
 		w.CarveRoom(newRoom)
+		w.Rooms = append(w.Rooms, newRoom)
 
-		// There is a chance that a creature entity is placed randomly
-		// in the room.
-
-		// Pick a random location in the room.
-		cx := randInt(rng, newRoom.X, newRoom.X+newRoom.W)
-		cy := randInt(rng, newRoom.Y, newRoom.Y+newRoom.H)
-		w.Entities = append(w.Entities, NewEntity(cx, cy, MonsterEntities[rng.Intn(len(MonsterEntities))]))
+		// Some rooms are lit by a light source in the center.
+		if rng.Intn(4) == 0 {
+			w.Lights = append(w.Lights, &Light{
+				X:      newRoom.X + newRoom.W/2,
+				Y:      newRoom.Y + newRoom.H/2,
+				Radius: max(newRoom.W, newRoom.H),
+			})
+		}
 
 		// Draw a tunnel between the rooms.
 		// NOTE: Right now, we just place the door in the middle.
@@ -182,6 +248,16 @@ type Room struct {
 	W, H int // width and height
 }
 
+// Center returns the center of the room.
+func (r *Room) Center() Point {
+	return Point{X: r.X + r.W/2, Y: r.Y + r.H/2}
+}
+
+// Contains returns true if the given position is within the room.
+func (r *Room) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.W && y >= r.Y && y < r.Y+r.H
+}
+
 // Overlaps returns true if the given room overlaps with any of the rooms in the list.
 func (r *Room) Overlaps(rooms []*Room) bool {
 	for _, room := range rooms {
@@ -196,6 +272,14 @@ func (r *Room) Overlaps(rooms []*Room) bool {
 	return false
 }
 
+// max returns the larger of the two given integers.
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 // randInt returns a random integer between min and max using the given rng.
 func randInt(rng *rand.Rand, min, max int) int {
 	return min + rng.Intn(max-min)
@@ -205,11 +289,37 @@ func randInt(rng *rand.Rand, min, max int) int {
 func GenWorldBigBox(width, height int, seed int64) *World {
 	w := NewWorld(width, height)
 	w.Fill('#')
-	w.CarveRoom(&Room{
+	room := &Room{
 		X: 1,
 		Y: 1,
 		W: width - 2,
 		H: height - 2,
-	})
+	}
+	w.CarveRoom(room)
+	w.Rooms = append(w.Rooms, room)
+	return w
+}
+
+// GenWorldDungeon generates a dungeon consisting of rooms connected by
+// mazes using the gendungeon package.
+func GenWorldDungeon(width, height int, seed int64) *World {
+	dng := gendungeon.Generate(width, height, gendungeon.RoomAttempts, gendungeon.MinRoomSize, gendungeon.MaxRoomSize, seed)
+	w := NewWorld(width, height)
+	w.Fill(TileWall)
+	for y := range dng.Tiles {
+		for x, t := range dng.Tiles[y] {
+			if t.Material != gendungeon.MatWall {
+				w.Cells[y][x] = TileFloor
+			}
+		}
+	}
+	for _, r := range dng.Rooms {
+		w.Rooms = append(w.Rooms, &Room{
+			X: r.Location.X,
+			Y: r.Location.Y,
+			W: r.Width,
+			H: r.Height,
+		})
+	}
 	return w
 }
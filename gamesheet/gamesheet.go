@@ -155,6 +155,18 @@ func (c *CharacterSheet) Tick(delta float64) {
 	}
 }
 
+// TickCounter returns the milliseconds accumulated towards the next
+// AP and HP regeneration.
+func (c *CharacterSheet) TickCounter() uint16 {
+	return c.msCounter
+}
+
+// SetTickCounter sets the milliseconds accumulated towards the next
+// AP and HP regeneration (used when restoring a saved character sheet).
+func (c *CharacterSheet) SetTickCounter(ms uint16) {
+	c.msCounter = ms
+}
+
 // Update updates the character sheet.
 func (c *CharacterSheet) Update() {
 	c.UpdatePoints()
@@ -0,0 +1,126 @@
+// Package genbiome provides functions for looking up the biome (e.g. forest,
+// grassland, etc.) for the given precipitation and average temperature, the
+// biome metadata and the Köppen climate classification.
+//
+// See: https://en.wikipedia.org/wiki/Biome#Whittaker_(1962,_1970,_1975)_biome-types
+package genbiome
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// Biome is a Whittaker biome type.
+type Biome int
+
+// The Whittaker biome types (plus ice for very cold regions).
+const (
+	BiomeIce                     Biome = iota // polar ice / snow
+	BiomeTundra                               // tundra
+	BiomeBorealForest                         // boreal forest / taiga
+	BiomeTemperateGrassland                   // temperate grassland / cold desert
+	BiomeWoodland                             // woodland / shrubland
+	BiomeTemperateSeasonalForest              // temperate seasonal forest
+	BiomeTemperateRainforest                  // temperate rainforest
+	BiomeSubtropicalDesert                    // subtropical desert
+	BiomeTropicalSeasonalForest               // tropical seasonal forest / savanna
+	BiomeTropicalRainforest                   // tropical rainforest
+	BiomeMax
+)
+
+// BiomeNames contains the names of the biomes.
+var BiomeNames = [BiomeMax]string{
+	BiomeIce:                     "ice",
+	BiomeTundra:                  "tundra",
+	BiomeBorealForest:            "boreal forest",
+	BiomeTemperateGrassland:      "temperate grassland",
+	BiomeWoodland:                "woodland",
+	BiomeTemperateSeasonalForest: "temperate seasonal forest",
+	BiomeTemperateRainforest:     "temperate rainforest",
+	BiomeSubtropicalDesert:       "subtropical desert",
+	BiomeTropicalSeasonalForest:  "tropical seasonal forest",
+	BiomeTropicalRainforest:      "tropical rainforest",
+}
+
+// BiomeColors contains the colors of the biomes.
+var BiomeColors = [BiomeMax]color.NRGBA{
+	BiomeIce:                     {R: 240, G: 248, B: 255, A: 255},
+	BiomeTundra:                  {R: 186, G: 190, B: 170, A: 255},
+	BiomeBorealForest:            {R: 91, G: 143, B: 82, A: 255},
+	BiomeTemperateGrassland:      {R: 200, G: 200, B: 120, A: 255},
+	BiomeWoodland:                {R: 180, G: 160, B: 90, A: 255},
+	BiomeTemperateSeasonalForest: {R: 60, G: 140, B: 60, A: 255},
+	BiomeTemperateRainforest:     {R: 30, G: 110, B: 70, A: 255},
+	BiomeSubtropicalDesert:       {R: 230, G: 200, B: 130, A: 255},
+	BiomeTropicalSeasonalForest:  {R: 160, G: 170, B: 50, A: 255},
+	BiomeTropicalRainforest:      {R: 20, G: 100, B: 30, A: 255},
+}
+
+// String returns the name of the biome.
+func (b Biome) String() string {
+	if !b.valid() {
+		return fmt.Sprintf("biome(%d)", int(b))
+	}
+	return BiomeNames[b]
+}
+
+// Whittaker returns the Whittaker biome for the given average yearly
+// temperature (in °C) and precipitation (in cm).
+func Whittaker(temperature, precipitation float64) Biome {
+	switch {
+	case temperature < -10:
+		return BiomeIce
+	case temperature < -5:
+		return BiomeTundra
+	case temperature < 3:
+		if precipitation < 25 {
+			return BiomeTundra
+		}
+		return BiomeBorealForest
+	case temperature < 20:
+		switch {
+		case precipitation < 25:
+			return BiomeTemperateGrassland
+		case precipitation < 75:
+			return BiomeWoodland
+		case precipitation < 200:
+			return BiomeTemperateSeasonalForest
+		}
+		return BiomeTemperateRainforest
+	}
+	switch {
+	case precipitation < 50:
+		return BiomeSubtropicalDesert
+	case precipitation < 250:
+		return BiomeTropicalSeasonalForest
+	}
+	return BiomeTropicalRainforest
+}
+
+// LapseRate is the decrease in temperature with elevation (in °C per meter).
+var LapseRate = 0.0065
+
+// AdjustTemperature returns the temperature at the given elevation (in meters
+// above sea level), given the temperature at sea level (in °C).
+// NOTE: Elevations below sea level are treated as sea level.
+func AdjustTemperature(temperature, elevation float64) float64 {
+	if elevation <= 0 {
+		return temperature
+	}
+	return temperature - elevation*LapseRate
+}
+
+// WhittakerElevation returns the Whittaker biome for the given average yearly
+// temperature at sea level (in °C), precipitation (in cm) and elevation (in
+// meters above sea level).
+func WhittakerElevation(temperature, precipitation, elevation float64) Biome {
+	return Whittaker(AdjustTemperature(temperature, elevation), precipitation)
+}
+
+// Color returns the color of the biome.
+func (b Biome) Color() color.NRGBA {
+	if !b.valid() {
+		return color.NRGBA{A: 255}
+	}
+	return BiomeColors[b]
+}
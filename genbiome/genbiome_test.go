@@ -0,0 +1,36 @@
+package genbiome
+
+import "testing"
+
+func TestWhittaker(t *testing.T) {
+	tests := []struct {
+		temperature, precipitation float64
+		want                       Biome
+	}{
+		{-10.01, 100, BiomeIce},
+		{-10, 100, BiomeTundra},
+		{-5.01, 300, BiomeTundra},
+		{-5, 24.9, BiomeTundra},
+		{-5, 25, BiomeBorealForest},
+		{2.99, 300, BiomeBorealForest},
+		{3, 24.9, BiomeTemperateGrassland},
+		{3, 25, BiomeWoodland},
+		{10, 50, BiomeWoodland},
+		{10, 74.9, BiomeWoodland},
+		{10, 75, BiomeTemperateSeasonalForest},
+		{19.99, 199.9, BiomeTemperateSeasonalForest},
+		{19.99, 200, BiomeTemperateRainforest},
+		{19.99, 250, BiomeTemperateRainforest},
+		{20, 25, BiomeSubtropicalDesert},
+		{20, 49.9, BiomeSubtropicalDesert},
+		{20, 50, BiomeTropicalSeasonalForest},
+		{20, 200, BiomeTropicalSeasonalForest},
+		{20, 249.9, BiomeTropicalSeasonalForest},
+		{20, 250, BiomeTropicalRainforest},
+	}
+	for _, tt := range tests {
+		if got := Whittaker(tt.temperature, tt.precipitation); got != tt.want {
+			t.Errorf("Whittaker(%g, %g) = %s, want %s", tt.temperature, tt.precipitation, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package genbiome
+
+import "math"
+
+// KoeppenGroups contains the names of the main Köppen climate groups.
+var KoeppenGroups = map[byte]string{
+	'A': "tropical",
+	'B': "arid",
+	'C': "temperate",
+	'D': "continental",
+	'E': "polar",
+}
+
+// Koeppen returns the Köppen climate classification (e.g. "Cfb") for the given
+// average monthly temperatures (in °C) and monthly precipitation (in mm).
+// The summer half-year is the warmer one, so this works for both hemispheres.
+//
+// See: https://en.wikipedia.org/wiki/K%C3%B6ppen_climate_classification
+func Koeppen(temperature, precipitation [12]float64) string {
+	// Determine the summer months (April - September or October - March).
+	var tAprSep, tOctMar float64
+	for m := 0; m < 12; m++ {
+		if m >= 3 && m < 9 {
+			tAprSep += temperature[m]
+		} else {
+			tOctMar += temperature[m]
+		}
+	}
+	isSummer := func(m int) bool {
+		return (m >= 3 && m < 9) == (tAprSep >= tOctMar)
+	}
+
+	tMin, tMax := math.Inf(1), math.Inf(-1)
+	pMin := math.Inf(1)
+	psMin, pwMin := math.Inf(1), math.Inf(1)
+	var psMax, pwMax float64
+	var tAnn, pAnn, pSummer float64
+	var warmMonths int
+	for m := 0; m < 12; m++ {
+		t, p := temperature[m], precipitation[m]
+		tAnn += t / 12
+		pAnn += p
+		tMin = math.Min(tMin, t)
+		tMax = math.Max(tMax, t)
+		pMin = math.Min(pMin, p)
+		if t >= 10 {
+			warmMonths++
+		}
+		if isSummer(m) {
+			pSummer += p
+			psMin = math.Min(psMin, p)
+			psMax = math.Max(psMax, p)
+		} else {
+			pwMin = math.Min(pwMin, p)
+			pwMax = math.Max(pwMax, p)
+		}
+	}
+
+	// Polar climates.
+	if tMax < 10 {
+		if tMax > 0 {
+			return "ET"
+		}
+		return "EF"
+	}
+
+	// Arid climates, where the precipitation threshold (in mm) depends on the
+	// temperature and when most of the precipitation falls.
+	pThreshold := 20 * tAnn
+	if pAnn > 0 && pSummer >= 0.7*pAnn {
+		pThreshold += 280
+	} else if pAnn > 0 && pSummer > 0.3*pAnn {
+		pThreshold += 140
+	}
+	if pAnn < pThreshold {
+		code := "BS"
+		if pAnn < pThreshold/2 {
+			code = "BW"
+		}
+		if tAnn >= 18 {
+			return code + "h"
+		}
+		return code + "k"
+	}
+
+	// Tropical climates.
+	if tMin >= 18 {
+		switch {
+		case pMin >= 60:
+			return "Af"
+		case pMin >= 100-pAnn/25:
+			return "Am"
+		}
+		return "Aw"
+	}
+
+	// Temperate and continental climates.
+	code := "D"
+	if tMin > 0 {
+		code = "C"
+	}
+	switch {
+	case psMin < 40 && psMin < pwMax/3:
+		code += "s" // dry summer
+	case pwMin < psMax/10:
+		code += "w" // dry winter
+	default:
+		code += "f" // no dry season
+	}
+	switch {
+	case tMax >= 22:
+		code += "a" // hot summer
+	case warmMonths >= 4:
+		code += "b" // warm summer
+	case code[0] == 'D' && tMin < -38:
+		code += "d" // very cold winter
+	default:
+		code += "c" // cold summer
+	}
+	return code
+}
+
+// MonthlyTemperatures returns the average monthly temperatures (in °C) for the
+// given average yearly temperature and the seasonal amplitude (half of the
+// difference between the warmest and the coldest month), with the warmest
+// month in July.
+func MonthlyTemperatures(temperature, amplitude float64) [12]float64 {
+	var res [12]float64
+	for m := range res {
+		res[m] = temperature - amplitude*math.Cos(2*math.Pi*(float64(m)+0.5)/12)
+	}
+	return res
+}
+
+// KoeppenYearly returns the Köppen climate classification for the given
+// average yearly temperature (in °C), seasonal temperature amplitude (in °C)
+// and yearly precipitation (in cm), which is assumed to be evenly distributed
+// over the year.
+// NOTE: This is a rough approximation for generators that only provide yearly
+// averages (see Koeppen).
+func KoeppenYearly(temperature, amplitude, precipitation float64) string {
+	var p [12]float64
+	for m := range p {
+		p[m] = precipitation * 10 / 12
+	}
+	return Koeppen(MonthlyTemperatures(temperature, amplitude), p)
+}
@@ -0,0 +1,63 @@
+package genbiome
+
+import "testing"
+
+func TestKoeppen(t *testing.T) {
+	tests := []struct {
+		name          string
+		temperature   [12]float64 // average monthly temperature (in °C)
+		precipitation [12]float64 // monthly precipitation (in mm)
+		want          string
+	}{{
+		name:          "London",
+		temperature:   [12]float64{5.2, 5.3, 7.6, 9.9, 13.3, 16.5, 18.7, 18.5, 15.7, 12.0, 8.0, 5.5},
+		precipitation: [12]float64{55, 41, 42, 44, 49, 45, 45, 50, 49, 69, 59, 55},
+		want:          "Cfb",
+	}, {
+		name:          "Cairo",
+		temperature:   [12]float64{14.0, 15.3, 17.6, 21.5, 24.9, 27.2, 27.9, 27.9, 26.2, 23.6, 19.1, 15.4},
+		precipitation: [12]float64{5, 4, 4, 1, 0, 0, 0, 0, 0, 1, 3, 5},
+		want:          "BWh",
+	}, {
+		// Southern hemisphere, so the summer is from October to March.
+		name:          "Sydney",
+		temperature:   [12]float64{23.5, 23.4, 22.1, 19.5, 16.6, 14.2, 13.4, 14.5, 17.0, 19.0, 20.7, 22.4},
+		precipitation: [12]float64{92, 130, 129, 127, 119, 132, 70, 80, 68, 77, 84, 77},
+		want:          "Cfa",
+	}, {
+		// Southern hemisphere with a dry summer.
+		name:          "Perth",
+		temperature:   [12]float64{24.5, 24.9, 23.0, 19.8, 16.3, 14.0, 13.0, 13.5, 14.9, 17.2, 20.2, 22.6},
+		precipitation: [12]float64{17, 13, 19, 36, 90, 128, 146, 122, 74, 41, 20, 11},
+		want:          "Csa",
+	}, {
+		// 600 mm spread evenly stays above the arid threshold of
+		// 20 * 20 + 140 mm.
+		name:          "even rain",
+		temperature:   [12]float64{20, 20, 20, 20, 20, 20, 20, 20, 20, 20, 20, 20},
+		precipitation: [12]float64{50, 50, 50, 50, 50, 50, 50, 50, 50, 50, 50, 50},
+		want:          "Aw",
+	}, {
+		// 600 mm falling mostly in summer is below the arid threshold of
+		// 20 * 20 + 280 mm.
+		name:          "summer rain",
+		temperature:   [12]float64{20, 20, 20, 20, 20, 20, 20, 20, 20, 20, 20, 20},
+		precipitation: [12]float64{10, 10, 10, 90, 90, 90, 90, 90, 90, 10, 10, 10},
+		want:          "BSh",
+	}, {
+		name:          "tundra",
+		temperature:   [12]float64{-20, -18, -15, -10, -2, 4, 9.9, 8, 2, -5, -12, -18},
+		precipitation: [12]float64{20, 20, 20, 20, 20, 20, 20, 20, 20, 20, 20, 20},
+		want:          "ET",
+	}, {
+		name:          "ice cap",
+		temperature:   [12]float64{-40, -38, -35, -30, -20, -10, 0, -5, -15, -25, -35, -40},
+		precipitation: [12]float64{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+		want:          "EF",
+	}}
+	for _, tt := range tests {
+		if got := Koeppen(tt.temperature, tt.precipitation); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package genbiome
+
+// BiomeClimates contains the typical average yearly temperature (in °C) and
+// precipitation (in cm) of the biomes.
+var BiomeClimates = [BiomeMax][2]float64{
+	BiomeIce:                     {-15, 20},
+	BiomeTundra:                  {-7, 30},
+	BiomeBorealForest:            {0, 50},
+	BiomeTemperateGrassland:      {10, 15},
+	BiomeWoodland:                {12, 50},
+	BiomeTemperateSeasonalForest: {12, 120},
+	BiomeTemperateRainforest:     {12, 250},
+	BiomeSubtropicalDesert:       {25, 20},
+	BiomeTropicalSeasonalForest:  {25, 150},
+	BiomeTropicalRainforest:      {26, 300},
+}
+
+// BiomeVegetation contains the typical vegetation of the biomes.
+var BiomeVegetation = [BiomeMax][]string{
+	BiomeIce:                     nil,
+	BiomeTundra:                  {"mosses", "lichens", "sedges", "dwarf shrubs"},
+	BiomeBorealForest:            {"spruce", "fir", "pine", "larch", "birch"},
+	BiomeTemperateGrassland:      {"grasses", "herbs", "sagebrush"},
+	BiomeWoodland:                {"shrubs", "oak", "juniper", "grasses"},
+	BiomeTemperateSeasonalForest: {"oak", "beech", "maple", "hickory", "ferns"},
+	BiomeTemperateRainforest:     {"redwood", "cedar", "hemlock", "mosses", "ferns"},
+	BiomeSubtropicalDesert:       {"cacti", "succulents", "creosote bush"},
+	BiomeTropicalSeasonalForest:  {"acacia", "baobab", "teak", "tall grasses"},
+	BiomeTropicalRainforest:      {"mahogany", "palms", "lianas", "epiphytes", "ferns"},
+}
+
+// BiomeVegetationDensity contains the typical density (0.0 - 1.0) of the
+// vegetation of the biomes.
+var BiomeVegetationDensity = [BiomeMax]float64{
+	BiomeIce:                     0.0,
+	BiomeTundra:                  0.2,
+	BiomeBorealForest:            0.7,
+	BiomeTemperateGrassland:      0.4,
+	BiomeWoodland:                0.5,
+	BiomeTemperateSeasonalForest: 0.8,
+	BiomeTemperateRainforest:     1.0,
+	BiomeSubtropicalDesert:       0.05,
+	BiomeTropicalSeasonalForest:  0.7,
+	BiomeTropicalRainforest:      1.0,
+}
+
+// BiomeHabitability contains the habitability (0.0 - 1.0) of the biomes for
+// humans, which can be used for placing settlements.
+var BiomeHabitability = [BiomeMax]float64{
+	BiomeIce:                     0.05,
+	BiomeTundra:                  0.2,
+	BiomeBorealForest:            0.4,
+	BiomeTemperateGrassland:      0.8,
+	BiomeWoodland:                0.7,
+	BiomeTemperateSeasonalForest: 0.9,
+	BiomeTemperateRainforest:     0.6,
+	BiomeSubtropicalDesert:       0.15,
+	BiomeTropicalSeasonalForest:  0.7,
+	BiomeTropicalRainforest:      0.4,
+}
+
+// BiomeAgriculturalYield contains the agricultural yield (0.0 - 1.0) of the
+// biomes.
+var BiomeAgriculturalYield = [BiomeMax]float64{
+	BiomeIce:                     0.0,
+	BiomeTundra:                  0.05,
+	BiomeBorealForest:            0.2,
+	BiomeTemperateGrassland:      0.8,
+	BiomeWoodland:                0.5,
+	BiomeTemperateSeasonalForest: 1.0,
+	BiomeTemperateRainforest:     0.6,
+	BiomeSubtropicalDesert:       0.05,
+	BiomeTropicalSeasonalForest:  0.7,
+	BiomeTropicalRainforest:      0.4,
+}
+
+// valid returns true if the biome is a known biome type.
+func (b Biome) valid() bool {
+	return b >= 0 && b < BiomeMax
+}
+
+// Climate returns the typical average yearly temperature (in °C) and
+// precipitation (in cm) of the biome.
+func (b Biome) Climate() (temperature, precipitation float64) {
+	if !b.valid() {
+		return 0, 0
+	}
+	return BiomeClimates[b][0], BiomeClimates[b][1]
+}
+
+// Vegetation returns the typical vegetation of the biome.
+func (b Biome) Vegetation() []string {
+	if !b.valid() {
+		return nil
+	}
+	return BiomeVegetation[b]
+}
+
+// VegetationDensity returns the typical density (0.0 - 1.0) of the vegetation
+// of the biome.
+func (b Biome) VegetationDensity() float64 {
+	if !b.valid() {
+		return 0
+	}
+	return BiomeVegetationDensity[b]
+}
+
+// Habitability returns the habitability (0.0 - 1.0) of the biome for humans.
+func (b Biome) Habitability() float64 {
+	if !b.valid() {
+		return 0
+	}
+	return BiomeHabitability[b]
+}
+
+// AgriculturalYield returns the agricultural yield (0.0 - 1.0) of the biome.
+func (b Biome) AgriculturalYield() float64 {
+	if !b.valid() {
+		return 0
+	}
+	return BiomeAgriculturalYield[b]
+}
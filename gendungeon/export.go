@@ -0,0 +1,477 @@
+package gendungeon
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaterialColors contains the colors used for each material when exporting
+// the dungeon as image.
+var MaterialColors = map[Material]color.NRGBA{
+	MatWall:       {R: 40, G: 40, B: 40, A: 255},
+	MatFloor:      {R: 200, G: 200, B: 200, A: 255},
+	MatDoor:       {R: 140, G: 90, B: 40, A: 255},
+	MatTunnel:     {R: 130, G: 130, B: 130, A: 255},
+	MatStairsUp:   {R: 80, G: 160, B: 220, A: 255},
+	MatStairsDown: {R: 30, G: 80, B: 180, A: 255},
+	MatChasm:      {R: 0, G: 0, B: 0, A: 255},
+}
+
+// Colors used for the markers placed by PlaceLocksAndKeys.
+var (
+	ColorEntrance = color.NRGBA{R: 0, G: 200, B: 0, A: 255}
+	ColorExit     = color.NRGBA{R: 200, G: 0, B: 0, A: 255}
+	ColorLock     = color.NRGBA{R: 200, G: 160, B: 0, A: 255}
+	ColorKey      = color.NRGBA{R: 255, G: 230, B: 0, A: 255}
+)
+
+// Image returns an image of the dungeon with each tile being tileSize pixels
+// wide. If byRegion is true, walkable tiles are colored by their region
+// instead of their material.
+func (dng *Dungeon) Image(tileSize int, byRegion bool) *image.NRGBA {
+	if tileSize < 1 {
+		tileSize = 1
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, dng.Width*tileSize, dng.Height*tileSize))
+	for y := 0; y < dng.Height; y++ {
+		for x := 0; x < dng.Width; x++ {
+			t := dng.Tiles[y][x]
+			col := MaterialColors[t.Material]
+			if byRegion && (t.Material == MatFloor || t.Material == MatTunnel) {
+				col = RegionColor(t.Region)
+			}
+			fillTile(img, x, y, tileSize, col)
+		}
+	}
+
+	// Draw the markers (if any).
+	for _, l := range dng.Locks {
+		fillTile(img, l.Door.X, l.Door.Y, tileSize, ColorLock)
+	}
+	for _, k := range dng.Keys {
+		fillTile(img, k.Position.X, k.Position.Y, tileSize, ColorKey)
+	}
+	if dng.Entrance != dng.Exit {
+		fillTile(img, dng.Entrance.X, dng.Entrance.Y, tileSize, ColorEntrance)
+		fillTile(img, dng.Exit.X, dng.Exit.Y, tileSize, ColorExit)
+	}
+	return img
+}
+
+// fillTile fills the given tile in the image with the color.
+func fillTile(img *image.NRGBA, x, y, tileSize int, col color.NRGBA) {
+	for py := y * tileSize; py < (y+1)*tileSize; py++ {
+		for px := x * tileSize; px < (x+1)*tileSize; px++ {
+			img.SetNRGBA(px, py, col)
+		}
+	}
+}
+
+// RegionColor returns a distinct color for the given region.
+func RegionColor(region int) color.NRGBA {
+	// Use the golden ratio to spread the hues evenly.
+	h := math.Mod(float64(region)*0.618033988749895, 1.0)
+	return hsvToRGB(h, 0.5, 0.9)
+}
+
+// hsvToRGB converts the given hue, saturation and value (0.0-1.0) to RGB.
+func hsvToRGB(h, s, v float64) color.NRGBA {
+	i := math.Floor(h * 6)
+	f := h*6 - i
+	p := v * (1 - s)
+	q := v * (1 - f*s)
+	t := v * (1 - (1-f)*s)
+	var r, g, b float64
+	switch int(i) % 6 {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+	return color.NRGBA{R: uint8(r * 255), G: uint8(g * 255), B: uint8(b * 255), A: 255}
+}
+
+// ExportPng exports the dungeon as PNG image to the given path.
+// See Image for the parameters.
+func (dng *Dungeon) ExportPng(path string, tileSize int, byRegion bool) error {
+	return writePng(path, dng.Image(tileSize, byRegion))
+}
+
+// writePng encodes the image as PNG and writes it to the given path.
+func writePng(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// JSONDungeon is the JSON representation of a dungeon.
+type JSONDungeon struct {
+	Width    int          `json:"width"`
+	Height   int          `json:"height"`
+	Tiles    [][]JSONTile `json:"tiles"` // [y][x]
+	Rooms    []JSONRoom   `json:"rooms"`
+	Doors    []JSONDoor   `json:"doors"`
+	Keys     []JSONKey    `json:"keys,omitempty"`
+	Entrance *JSONPoint   `json:"entrance,omitempty"`
+	Exit     *JSONPoint   `json:"exit,omitempty"`
+}
+
+// JSONTile is the JSON representation of a tile.
+type JSONTile struct {
+	Material string `json:"material"` // "wall", "floor", "door", "tunnel", "stairs_up", "stairs_down" or "chasm"
+	Region   int    `json:"region"`
+}
+
+// JSONRoom is the JSON representation of a room.
+type JSONRoom struct {
+	X      int      `json:"x"`
+	Y      int      `json:"y"`
+	Width  int      `json:"width"`
+	Height int      `json:"height"`
+	Mask   []string `json:"mask,omitempty"` // one string per row, '#' for floor tiles (omitted for rectangles)
+}
+
+// JSONDoor is the JSON representation of a door.
+type JSONDoor struct {
+	X     int   `json:"x"`
+	Y     int   `json:"y"`
+	Rooms []int `json:"rooms,omitempty"` // indices of the adjacent rooms
+	Lock  *int  `json:"lock,omitempty"`  // ID of the lock (if locked)
+}
+
+// JSONKey is the JSON representation of a key.
+type JSONKey struct {
+	ID int `json:"id"` // ID of the lock the key opens
+	X  int `json:"x"`
+	Y  int `json:"y"`
+}
+
+// JSONPoint is the JSON representation of a position.
+type JSONPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// String returns the name of the material.
+func (m Material) String() string {
+	switch m {
+	case MatWall:
+		return "wall"
+	case MatFloor:
+		return "floor"
+	case MatDoor:
+		return "door"
+	case MatTunnel:
+		return "tunnel"
+	case MatStairsUp:
+		return "stairs_up"
+	case MatStairsDown:
+		return "stairs_down"
+	case MatChasm:
+		return "chasm"
+	}
+	return fmt.Sprintf("material(%d)", int(m))
+}
+
+// JSON returns the JSON representation of the dungeon.
+func (dng *Dungeon) JSON() *JSONDungeon {
+	res := &JSONDungeon{
+		Width:  dng.Width,
+		Height: dng.Height,
+		Tiles:  make([][]JSONTile, dng.Height),
+	}
+	for _, k := range dng.Keys {
+		res.Keys = append(res.Keys, JSONKey{
+			ID: k.ID,
+			X:  k.Position.X,
+			Y:  k.Position.Y,
+		})
+	}
+	if dng.Entrance != dng.Exit {
+		res.Entrance = &JSONPoint{X: dng.Entrance.X, Y: dng.Entrance.Y}
+		res.Exit = &JSONPoint{X: dng.Exit.X, Y: dng.Exit.Y}
+	}
+	for y := range res.Tiles {
+		res.Tiles[y] = make([]JSONTile, dng.Width)
+		for x, t := range dng.Tiles[y] {
+			res.Tiles[y][x] = JSONTile{
+				Material: t.Material.String(),
+				Region:   t.Region,
+			}
+		}
+	}
+	for _, r := range dng.Rooms {
+		jr := JSONRoom{
+			X:      r.Location.X,
+			Y:      r.Location.Y,
+			Width:  r.Width,
+			Height: r.Height,
+		}
+		if r.Mask != nil {
+			for y := 0; y < r.Height; y++ {
+				var sb strings.Builder
+				for x := 0; x < r.Width; x++ {
+					if r.IsFloor(x, y) {
+						sb.WriteByte('#')
+					} else {
+						sb.WriteByte('.')
+					}
+				}
+				jr.Mask = append(jr.Mask, sb.String())
+			}
+		}
+		res.Rooms = append(res.Rooms, jr)
+	}
+
+	// Add the doors with the rooms they connect.
+	g := dng.Graph()
+	locks := make(map[Point]int)
+	for _, l := range dng.Locks {
+		locks[l.Door] = l.ID
+	}
+	for y := 0; y < dng.Height; y++ {
+		for x := 0; x < dng.Width; x++ {
+			if dng.Tiles[y][x].Material != MatDoor {
+				continue
+			}
+			d := JSONDoor{X: x, Y: y}
+			for _, nb := range dng.neighbors4(x, y) {
+				if id := g.NodeAt(nb.X, nb.Y); id != -1 && g.Nodes[id].Kind == NodeRoom && !containsInt(d.Rooms, g.Nodes[id].Room) {
+					d.Rooms = append(d.Rooms, g.Nodes[id].Room)
+				}
+			}
+			if id, ok := locks[Point{X: x, Y: y}]; ok {
+				d.Lock = &id
+			}
+			res.Doors = append(res.Doors, d)
+		}
+	}
+	return res
+}
+
+// ExportJSON exports the dungeon as JSON to the given path.
+func (dng *Dungeon) ExportJSON(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(dng.JSON()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// tmxMap is the root element of a Tiled TMX map.
+// See: https://doc.mapeditor.org/en/stable/reference/tmx-map-format/
+type tmxMap struct {
+	XMLName      xml.Name         `xml:"map"`
+	Version      string           `xml:"version,attr"`
+	Orientation  string           `xml:"orientation,attr"`
+	RenderOrder  string           `xml:"renderorder,attr"`
+	Width        int              `xml:"width,attr"`
+	Height       int              `xml:"height,attr"`
+	TileWidth    int              `xml:"tilewidth,attr"`
+	TileHeight   int              `xml:"tileheight,attr"`
+	Infinite     int              `xml:"infinite,attr"`
+	NextLayerID  int              `xml:"nextlayerid,attr"`
+	NextObjectID int              `xml:"nextobjectid,attr"`
+	Tileset      tmxTileset       `xml:"tileset"`
+	Layers       []tmxLayer       `xml:"layer"`
+	ObjectGroups []tmxObjectGroup `xml:"objectgroup"`
+}
+
+type tmxTileset struct {
+	FirstGID   int      `xml:"firstgid,attr"`
+	Name       string   `xml:"name,attr"`
+	TileWidth  int      `xml:"tilewidth,attr"`
+	TileHeight int      `xml:"tileheight,attr"`
+	TileCount  int      `xml:"tilecount,attr"`
+	Columns    int      `xml:"columns,attr"`
+	Image      tmxImage `xml:"image"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+type tmxLayer struct {
+	ID     int     `xml:"id,attr"`
+	Name   string  `xml:"name,attr"`
+	Width  int     `xml:"width,attr"`
+	Height int     `xml:"height,attr"`
+	Data   tmxData `xml:"data"`
+}
+
+type tmxData struct {
+	Encoding string `xml:"encoding,attr"`
+	Data     string `xml:",chardata"`
+}
+
+type tmxObjectGroup struct {
+	ID      int         `xml:"id,attr"`
+	Name    string      `xml:"name,attr"`
+	Objects []tmxObject `xml:"object"`
+}
+
+type tmxObject struct {
+	ID     int       `xml:"id,attr"`
+	Name   string    `xml:"name,attr,omitempty"`
+	Type   string    `xml:"type,attr,omitempty"`
+	X      int       `xml:"x,attr"`
+	Y      int       `xml:"y,attr"`
+	Width  int       `xml:"width,attr,omitempty"`
+	Height int       `xml:"height,attr,omitempty"`
+	Point  *struct{} `xml:"point,omitempty"`
+}
+
+// tmxMaterials is the order of the materials in the exported tileset.
+var tmxMaterials = []Material{MatWall, MatFloor, MatDoor, MatTunnel, MatStairsUp, MatStairsDown, MatChasm}
+
+// ExportTMX exports the dungeon as Tiled TMX map to the given path, which
+// can be opened and edited with the Tiled map editor (https://www.mapeditor.org/).
+//
+// The map contains a tile layer with the materials and object layers with
+// the rooms and the markers placed by PlaceLocksAndKeys.
+// The tileset image is stored next to the map as "<name>_tiles.png".
+func (dng *Dungeon) ExportTMX(path string, tileSize int) error {
+	if tileSize < 1 {
+		tileSize = 1
+	}
+
+	// Write the tileset image with one tile per material.
+	tilesetPath := strings.TrimSuffix(path, filepath.Ext(path)) + "_tiles.png"
+	tileset := image.NewNRGBA(image.Rect(0, 0, len(tmxMaterials)*tileSize, tileSize))
+	gids := make(map[Material]int)
+	for i, m := range tmxMaterials {
+		fillTile(tileset, i, 0, tileSize, MaterialColors[m])
+		gids[m] = i + 1 // GID 0 is an empty tile.
+	}
+	if err := writePng(tilesetPath, tileset); err != nil {
+		return err
+	}
+
+	m := tmxMap{
+		Version:     "1.10",
+		Orientation: "orthogonal",
+		RenderOrder: "right-down",
+		Width:       dng.Width,
+		Height:      dng.Height,
+		TileWidth:   tileSize,
+		TileHeight:  tileSize,
+		Tileset: tmxTileset{
+			FirstGID:   1,
+			Name:       "materials",
+			TileWidth:  tileSize,
+			TileHeight: tileSize,
+			TileCount:  len(tmxMaterials),
+			Columns:    len(tmxMaterials),
+			Image: tmxImage{
+				Source: filepath.Base(tilesetPath),
+				Width:  len(tmxMaterials) * tileSize,
+				Height: tileSize,
+			},
+		},
+	}
+
+	// Add the material layer as CSV.
+	var sb strings.Builder
+	for y := 0; y < dng.Height; y++ {
+		for x := 0; x < dng.Width; x++ {
+			fmt.Fprintf(&sb, "%d", gids[dng.Tiles[y][x].Material])
+			if x < dng.Width-1 || y < dng.Height-1 {
+				sb.WriteByte(',')
+			}
+		}
+	}
+	m.Layers = append(m.Layers, tmxLayer{
+		ID:     1,
+		Name:   "materials",
+		Width:  dng.Width,
+		Height: dng.Height,
+		Data:   tmxData{Encoding: "csv", Data: sb.String()},
+	})
+
+	// Add the rooms as rectangles and the markers as points.
+	nextID := 1
+	rooms := tmxObjectGroup{ID: 2, Name: "rooms"}
+	for i, r := range dng.Rooms {
+		rooms.Objects = append(rooms.Objects, tmxObject{
+			ID:     nextID,
+			Name:   fmt.Sprintf("room %d", i),
+			Type:   "room",
+			X:      r.Location.X * tileSize,
+			Y:      r.Location.Y * tileSize,
+			Width:  r.Width * tileSize,
+			Height: r.Height * tileSize,
+		})
+		nextID++
+	}
+	markers := tmxObjectGroup{ID: 3, Name: "markers"}
+	addMarker := func(name, typ string, p Point) {
+		markers.Objects = append(markers.Objects, tmxObject{
+			ID:    nextID,
+			Name:  name,
+			Type:  typ,
+			X:     p.X*tileSize + tileSize/2,
+			Y:     p.Y*tileSize + tileSize/2,
+			Point: &struct{}{},
+		})
+		nextID++
+	}
+	if dng.Entrance != dng.Exit {
+		addMarker("entrance", "entrance", dng.Entrance)
+		addMarker("exit", "exit", dng.Exit)
+	}
+	for _, l := range dng.Locks {
+		addMarker(fmt.Sprintf("lock %d", l.ID), "lock", l.Door)
+	}
+	for _, k := range dng.Keys {
+		addMarker(fmt.Sprintf("key %d", k.ID), "key", k.Position)
+	}
+	m.ObjectGroups = append(m.ObjectGroups, rooms, markers)
+	m.NextLayerID = 4
+	m.NextObjectID = nextID
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(xml.Header); err != nil {
+		f.Close()
+		return err
+	}
+	enc := xml.NewEncoder(f)
+	enc.Indent("", " ")
+	if err := enc.Encode(m); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
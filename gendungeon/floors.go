@@ -0,0 +1,317 @@
+package gendungeon
+
+// Floor is a single floor of a multi-floor dungeon.
+type Floor struct {
+	*Dungeon
+	Depth      int     // depth of the floor (0 is the top floor)
+	Difficulty float64 // difficulty of the floor (increases with depth)
+	StairsUp   Point   // position of the stairs up (aligned with the stairs down of the floor above)
+	StairsDown *Point  // position of the stairs down (nil on the bottom floor)
+}
+
+// Shaft is a vertical shaft (or chasm) spanning one or more floors. On all
+// floors from Top to Bottom-1 the tiles are chasms, and on the Bottom floor
+// they are the floor tiles where everything falling down the shaft lands.
+type Shaft struct {
+	Tiles  []Point // tiles of the shaft (identical on all floors)
+	Top    int     // depth of the topmost floor with a chasm
+	Bottom int     // depth of the floor where the shaft ends
+}
+
+// MultiDungeon is a stack of floors connected by stairs and shafts.
+type MultiDungeon struct {
+	Floors []*Floor
+	Shafts []*Shaft
+}
+
+// FloorOptions contains the parameters for the multi-floor generation.
+type FloorOptions struct {
+	Options                     // options used for each floor (the seed is varied per floor)
+	Floors              int     // number of floors
+	BaseDifficulty      float64 // difficulty of the top floor
+	DifficultyPerFloor  float64 // difficulty added with each floor
+	LockLayers          int     // number of lock layers on the top floor (see PlaceLocksAndKeys)
+	LockLayersPerFloor  float64 // number of lock layers added with each floor (rounded down)
+	ShaftChance         float64 // chance (0.0-1.0) that a floor gets a new shaft to the floor below
+	ShaftContinueChance float64 // chance (0.0-1.0) that a shaft continues to the next floor
+	ShaftSize           int     // maximum radius of a shaft in tiles
+}
+
+// DefaultFloorOptions returns the suggested default options for a dungeon
+// with the given number of floors, size and seed.
+func DefaultFloorOptions(floors, width, height int, seed int64) FloorOptions {
+	return FloorOptions{
+		Options:             DefaultOptions(width, height, seed),
+		Floors:              floors,
+		BaseDifficulty:      1.0,
+		DifficultyPerFloor:  0.5,
+		LockLayers:          1,
+		LockLayersPerFloor:  0.34,
+		ShaftChance:         0.3,
+		ShaftContinueChance: 0.3,
+		ShaftSize:           1,
+	}
+}
+
+// GenerateFloors generates a stack of floors, where the stairs down of each
+// floor line up with the stairs up of the floor below, and shafts (chasms)
+// line up with the landing area on the floors below.
+//
+// Each floor is solvable on its own, with the entrance at the stairs up and
+// the exit at the stairs down (see PlaceLocksAndKeys).
+func GenerateFloors(opts FloorOptions) *MultiDungeon {
+	md := &MultiDungeon{}
+	var stairs *Point // stairs down of the floor above
+	var open []*Shaft // shafts reaching into the current floor
+	for depth := 0; depth < opts.Floors; depth++ {
+		fopts := opts.Options
+		fopts.Seed = opts.Seed + int64(depth)*7919
+
+		// Reserve the space for the landing areas of the stairs and shafts
+		// from the floor above.
+		var fixed []Room
+		if stairs != nil {
+			fixed = addFixedRoom(fixed, landingRoom([]Point{*stairs}, 1, fopts.Width, fopts.Height))
+		}
+		for _, s := range open {
+			fixed = addFixedRoom(fixed, landingRoom(s.Tiles, 1, fopts.Width, fopts.Height))
+		}
+		fopts.FixedRooms = append(fixed, fopts.FixedRooms...)
+
+		// NOTE: The generator does not guarantee that all rooms are connected,
+		// so we retry with a different seed if a landing area is unreachable.
+		var dng *Dungeon
+		var g *Graph
+		for attempt := 0; attempt < maxFloorAttempts; attempt++ {
+			dng = GenerateWithOptions(fopts)
+			g = dng.Graph()
+			if dng.fixedRoomsConnected(g, len(fixed)) {
+				break
+			}
+			fopts.Seed += 104729
+		}
+		f := &Floor{
+			Dungeon:    dng,
+			Depth:      depth,
+			Difficulty: opts.BaseDifficulty + float64(depth)*opts.DifficultyPerFloor,
+		}
+
+		// Place the stairs up where the stairs down of the floor above are
+		// (or in a random room on the top floor).
+		if stairs != nil {
+			f.StairsUp = *stairs
+		} else if len(dng.Rooms) > 0 {
+			f.StairsUp = g.Nodes[g.RoomNode(dng.rand.Intn(len(dng.Rooms)))].Center()
+		}
+		dng.Tiles[f.StairsUp.Y][f.StairsUp.X].Material = MatStairsUp
+		entrance := g.NodeAt(f.StairsUp.X, f.StairsUp.Y)
+
+		// Continue or end the shafts from the floor above.
+		var next []*Shaft
+		landing := make(map[Point]bool)
+		for _, s := range open {
+			if depth < opts.Floors-1 && dng.rand.Float64() < opts.ShaftContinueChance {
+				dng.setMaterial(s.Tiles, MatChasm)
+				next = append(next, s)
+			} else {
+				s.Bottom = depth
+				for _, p := range s.Tiles {
+					landing[p] = true
+				}
+			}
+		}
+
+		// Place the stairs down in the room farthest from the stairs up (on
+		// the bottom floor, this is the boss room).
+		exit := entrance
+		if far := g.FarthestRoom(entrance); far != -1 {
+			exit = far
+		}
+		if depth < opts.Floors-1 {
+			p := dng.randomTileOf(g.Nodes[exit], MatFloor, landing)
+			dng.Tiles[p.Y][p.X].Material = MatStairsDown
+			f.StairsDown = &p
+		}
+
+		// Maybe open up a new shaft to the floor below.
+		if depth < opts.Floors-1 && dng.rand.Float64() < opts.ShaftChance {
+			if tiles := dng.shaftTiles(g, opts.ShaftSize, landing, entrance, exit); len(tiles) > 0 {
+				dng.setMaterial(tiles, MatChasm)
+				s := &Shaft{Tiles: tiles, Top: depth}
+				md.Shafts = append(md.Shafts, s)
+				next = append(next, s)
+			}
+		}
+		open = next
+
+		// Place the locks and keys between the stairs.
+		dng.Entrance = f.StairsUp
+		dng.Exit = g.Nodes[exit].Center()
+		if f.StairsDown != nil {
+			dng.Exit = *f.StairsDown
+		}
+		dng.Locks = nil
+		dng.Keys = nil
+		if layers := opts.LockLayers + int(float64(depth)*opts.LockLayersPerFloor); layers > 0 && exit != entrance {
+			dng.placeLocks(dng.Graph(), entrance, exit, layers)
+		}
+
+		md.Floors = append(md.Floors, f)
+		stairs = f.StairsDown
+	}
+	return md
+}
+
+// maxFloorAttempts is the number of attempts to generate a floor with all
+// landing areas connected.
+const maxFloorAttempts = 10
+
+// fixedRoomsConnected returns true if the first n rooms (the fixed rooms)
+// are connected to each other.
+func (dng *Dungeon) fixedRoomsConnected(g *Graph, n int) bool {
+	if n < 2 {
+		return true
+	}
+	dist := g.Distances(g.RoomNode(0))
+	for i := 1; i < n; i++ {
+		if id := g.RoomNode(i); id == -1 || dist[id] == -1 {
+			return false
+		}
+	}
+	return true
+}
+
+// setMaterial sets the material of all given tiles.
+func (dng *Dungeon) setMaterial(tiles []Point, m Material) {
+	for _, p := range tiles {
+		dng.Tiles[p.Y][p.X].Material = m
+	}
+}
+
+// randomTileOf returns a random tile of the node with the given material
+// that is not taken yet (or the center of the node if there is none).
+func (dng *Dungeon) randomTileOf(n *Node, m Material, taken map[Point]bool) Point {
+	for _, i := range dng.rand.Perm(len(n.Tiles)) {
+		if p := n.Tiles[i]; dng.Tiles[p.Y][p.X].Material == m && !taken[p] {
+			return p
+		}
+	}
+	return n.Center()
+}
+
+// shaftTiles picks a rectangular room reachable from the entrance (other
+// than the entrance and exit rooms) and returns the tiles of a shaft with the
+// given maximum radius around its center. Only tiles surrounded by room floor
+// are used, so the room stays connected around the shaft. Taken tiles are
+// skipped.
+func (dng *Dungeon) shaftTiles(g *Graph, radius int, taken map[Point]bool, entrance, exit int) []Point {
+	dist := g.Distances(entrance)
+	for _, i := range dng.rand.Perm(len(dng.Rooms)) {
+		r := dng.Rooms[i]
+		id := g.RoomNode(i)
+		if r.Mask != nil || id == -1 || dist[id] == -1 || id == entrance || id == exit {
+			continue
+		}
+		c := Point{X: r.Location.X + r.Width/2, Y: r.Location.Y + r.Height/2}
+		var tiles []Point
+		for y := c.Y - radius; y <= c.Y+radius; y++ {
+			for x := c.X - radius; x <= c.X+radius; x++ {
+				if !taken[Point{X: x, Y: y}] && dng.isSurroundedByFloor(x, y) {
+					tiles = append(tiles, Point{X: x, Y: y})
+				}
+			}
+		}
+		if len(tiles) > 0 {
+			return tiles
+		}
+	}
+	return nil
+}
+
+// isSurroundedByFloor returns true if the tile and all its 8 neighbors are
+// room floor.
+func (dng *Dungeon) isSurroundedByFloor(x, y int) bool {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if !dng.inBounds(x+dx, y+dy) || dng.Tiles[y+dy][x+dx].Material != MatFloor {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// landingRoom returns a rectangular room enclosing the given tiles with the
+// given margin, clamped to the dungeon bounds.
+func landingRoom(tiles []Point, margin, width, height int) Room {
+	min, max := tiles[0], tiles[0]
+	for _, p := range tiles {
+		if p.X < min.X {
+			min.X = p.X
+		}
+		if p.Y < min.Y {
+			min.Y = p.Y
+		}
+		if p.X > max.X {
+			max.X = p.X
+		}
+		if p.Y > max.Y {
+			max.Y = p.Y
+		}
+	}
+	min.X, min.Y = clampInt(min.X-margin, 1, width-2), clampInt(min.Y-margin, 1, height-2)
+	max.X, max.Y = clampInt(max.X+margin, 1, width-2), clampInt(max.Y+margin, 1, height-2)
+	return Room{
+		Location: min,
+		Width:    max.X - min.X + 1,
+		Height:   max.Y - min.Y + 1,
+	}
+}
+
+// addFixedRoom adds the room to the list, merging it with any overlapping
+// (or touching) rooms into their bounding rectangle.
+func addFixedRoom(rooms []Room, r Room) []Room {
+	for {
+		merged := false
+		for i, o := range rooms {
+			if r.Location.X > o.Location.X+o.Width || o.Location.X > r.Location.X+r.Width ||
+				r.Location.Y > o.Location.Y+o.Height || o.Location.Y > r.Location.Y+r.Height {
+				continue
+			}
+			minX, minY := minInt(r.Location.X, o.Location.X), minInt(r.Location.Y, o.Location.Y)
+			maxX := maxInt(r.Location.X+r.Width, o.Location.X+o.Width)
+			maxY := maxInt(r.Location.Y+r.Height, o.Location.Y+o.Height)
+			r = Room{Location: Point{X: minX, Y: minY}, Width: maxX - minX, Height: maxY - minY}
+			rooms = append(rooms[:i], rooms[i+1:]...)
+			merged = true
+			break
+		}
+		if !merged {
+			return append(rooms, r)
+		}
+	}
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -14,10 +14,13 @@ type Material int
 
 // The various valid materials.
 const (
-	MatWall   Material = iota // stone wall
-	MatFloor                  // room floor
-	MatDoor                   // door
-	MatTunnel                 // tunnel / maze
+	MatWall       Material = iota // stone wall
+	MatFloor                      // room floor
+	MatDoor                       // door
+	MatTunnel                     // tunnel / maze
+	MatStairsUp                   // stairs to the floor above (see GenerateFloors)
+	MatStairsDown                 // stairs to the floor below (see GenerateFloors)
+	MatChasm                      // chasm / shaft to the floor below (see GenerateFloors)
 )
 
 // Point is a point at a specific x,y coordinate.
@@ -34,10 +37,20 @@ type Tile struct {
 
 // Room represents a room in the dungeon.
 type Room struct {
-	Width    int     // width of the room
-	Height   int     // height of the room
-	Location Point   // top left corner of the room
-	Edges    []Point // the edges of the room
+	Width    int      // width of the room
+	Height   int      // height of the room
+	Location Point    // top left corner of the room
+	Edges    []Point  // the edges of the room
+	Mask     [][]bool // floor tiles within the bounding box ([y][x], nil for rectangular rooms)
+}
+
+// IsFloor returns true if the given tile (relative to the top left corner)
+// is part of the room.
+func (r *Room) IsFloor(dx, dy int) bool {
+	if dx < 0 || dy < 0 || dx >= r.Width || dy >= r.Height {
+		return false
+	}
+	return r.Mask == nil || r.Mask[dy][dx]
 }
 
 // Dungeon represents a generated dungeon.
@@ -46,17 +59,24 @@ type Dungeon struct {
 	Rooms      []Room     // rooms in the dungeon
 	Width      int        // width of the dungeon
 	Height     int        // height of the dungeon
+	Entrance   Point      // entrance of the dungeon (see PlaceLocksAndKeys)
+	Exit       Point      // exit / boss room of the dungeon (see PlaceLocksAndKeys)
+	Locks      []Lock     // locked doors (see PlaceLocksAndKeys)
+	Keys       []Key      // keys for the locked doors (see PlaceLocksAndKeys)
 	numRegions int        // number of regions in the dungeon
 	rand       *rand.Rand // rand initialized with the seed
+	opts       Options    // options used for generation
 }
 
-func createEmptyDungeon(width, height int, seed int64) *Dungeon {
-	fmt.Println("Creating empty dungeon...")
+func createEmptyDungeon(opts Options) *Dungeon {
 	dng := &Dungeon{
-		Width:  width,
-		Height: height,
-		rand:   rand.New(rand.NewSource(seed)),
+		Width:  opts.Width,
+		Height: opts.Height,
+		rand:   rand.New(rand.NewSource(opts.Seed)),
+		opts:   opts,
 	}
+	dng.logf("Creating empty dungeon...")
+	width, height := opts.Width, opts.Height
 	dng.Tiles = make([][]Tile, height)
 	for i := range dng.Tiles {
 		dng.Tiles[i] = make([]Tile, width)
@@ -65,8 +85,9 @@ func createEmptyDungeon(width, height int, seed int64) *Dungeon {
 }
 
 func (dng *Dungeon) createRooms(minSize, maxSize, attempts int) {
-	fmt.Println("Creating rooms...")
-	var rooms []Room
+	dng.logf("Creating rooms...")
+	// Start with the fixed rooms, so the random rooms are placed around them.
+	rooms := append([]Room(nil), dng.opts.FixedRooms...)
 	for i := 0; i < attempts; i++ {
 		width := dng.rand.Intn(maxSize-minSize) + minSize
 		height := dng.rand.Intn(maxSize-minSize) + minSize
@@ -94,6 +115,7 @@ func (dng *Dungeon) createRooms(minSize, maxSize, attempts int) {
 				Width:    width,
 				Height:   height,
 				Location: Point{X: x, Y: y},
+				Mask:     dng.roomMask(width, height),
 			})
 		}
 	}
@@ -102,6 +124,9 @@ func (dng *Dungeon) createRooms(minSize, maxSize, attempts int) {
 		dng.numRegions++
 		for i := r.Location.X; i < r.Location.X+r.Width; i++ {
 			for j := r.Location.Y; j < r.Location.Y+r.Height; j++ {
+				if !r.IsFloor(i-r.Location.X, j-r.Location.Y) {
+					continue
+				}
 				dng.Tiles[j][i].Material = MatFloor
 				dng.Tiles[j][i].Region = dng.numRegions
 			}
@@ -112,7 +137,7 @@ func (dng *Dungeon) createRooms(minSize, maxSize, attempts int) {
 }
 
 func (dng *Dungeon) createMaze() {
-	fmt.Println("Creating tunnels...")
+	dng.logf("Creating tunnels...")
 
 	// Iterate through all tiles and start growing the maze from each elegible tile.
 	for x := 1; x < dng.Width-1; x++ {
@@ -135,13 +160,15 @@ func (dng *Dungeon) createMaze() {
 				dng.Tiles[y+1][x+1].Material == MatWall {
 
 				dng.numRegions++
-				dng.continueMaze(x, y)
+				dng.continueMaze(x, y, Point{})
 			}
 		}
 	}
 }
 
-func (dng *Dungeon) continueMaze(x, y int) {
+// continueMaze digs the maze from the given tile, which was entered in
+// the given direction.
+func (dng *Dungeon) continueMaze(x, y int, dir Point) {
 	var validTiles []Point
 
 	// Check if we can dig left.
@@ -214,9 +241,13 @@ func (dng *Dungeon) continueMaze(x, y int) {
 	}
 
 	// From all valid tiles, pick one (at random if we have more than one)
-	// to dig a tunnel to.
+	// to dig a tunnel to. Depending on the winding percentage, we prefer
+	// to continue in the same direction.
 	var idx int
-	if len(validTiles) > 1 {
+	if straight := indexOfPoint(validTiles, Point{X: x + dir.X, Y: y + dir.Y}); dir != (Point{}) && straight != -1 &&
+		dng.opts.WindingPercent < 100 && dng.rand.Intn(100) >= dng.opts.WindingPercent {
+		idx = straight
+	} else if len(validTiles) > 1 {
 		idx = dng.rand.Intn(len(validTiles))
 	}
 
@@ -226,15 +257,29 @@ func (dng *Dungeon) continueMaze(x, y int) {
 	dng.Tiles[point.Y][point.X].Region = dng.numRegions
 
 	// Continue digging the maze...
-	dng.continueMaze(point.X, point.Y) // ... from the new tile.
-	dng.continueMaze(x, y)             // ... from the current tile.
+	dng.continueMaze(point.X, point.Y, Point{X: point.X - x, Y: point.Y - y}) // ... from the new tile.
+	dng.continueMaze(x, y, dir)                                               // ... from the current tile.
+}
+
+// indexOfPoint returns the index of the point in the slice (-1 if not found).
+func indexOfPoint(points []Point, p Point) int {
+	for i, v := range points {
+		if v == p {
+			return i
+		}
+	}
+	return -1
 }
 
 func (dng *Dungeon) identifyEdges() {
-	fmt.Println("Identifying edges...")
+	dng.logf("Identifying edges...")
 
 	// Iterate through all rooms and identify edges.
 	for i := range dng.Rooms {
+		if dng.Rooms[i].Mask != nil {
+			dng.identifyShapedEdges(&dng.Rooms[i])
+			continue
+		}
 		x := dng.Rooms[i].Location.X
 		y := dng.Rooms[i].Location.Y
 
@@ -267,13 +312,16 @@ func (dng *Dungeon) identifyEdges() {
 }
 
 func (dng *Dungeon) connectRegions() {
-	fmt.Println("Conneting regions...")
+	dng.logf("Connecting regions...")
 
 	// Iterate through all rooms and connect them to the corridors or other rooms.
 	for i, room := range dng.Rooms {
+		if len(room.Edges) == 0 {
+			continue // The room is isolated.
+		}
 		// Pick a random edge to connect to.
 		edge := room.Edges[dng.rand.Intn(len(dng.Rooms[i].Edges))]
-		roomRegion := dng.Tiles[dng.Rooms[i].Location.Y][dng.Rooms[i].Location.X].Region
+		roomRegion := dng.roomRegion(&dng.Rooms[i])
 
 		// The neighboring tiles.
 		nbs := [8]Tile{
@@ -296,7 +344,9 @@ func (dng *Dungeon) connectRegions() {
 				dng.Tiles[edge.Y][edge.X].Material = MatDoor
 				for x := room.Location.X; x < room.Location.X+room.Width; x++ {
 					for y := room.Location.Y; y < room.Location.Y+room.Height; y++ {
-						dng.Tiles[y][x].Region = nbs[j].Region
+						if room.IsFloor(x-room.Location.X, y-room.Location.Y) {
+							dng.Tiles[y][x].Region = nbs[j].Region
+						}
 					}
 				}
 				break // We found a suitable edge, so stop looking.
@@ -342,10 +392,15 @@ RoomsLoop:
 			}
 		}
 	}
+
+	// Add extra doors to create loops.
+	if dng.opts.ExtraConnectionChance > 0 {
+		dng.addExtraConnections()
+	}
 }
 
 func (dng *Dungeon) trimTunnels() {
-	fmt.Println("Trimming tunnels...")
+	dng.logf("Trimming tunnels...")
 	for x := 1; x < dng.Width-1; x++ {
 		for y := 1; y < dng.Height-1; y++ {
 			dng.continueTrimTunnels(x, y)
@@ -407,6 +462,12 @@ func (dng *Dungeon) RenderToConsole() {
 				fmt.Print("| ")
 			case MatTunnel:
 				fmt.Print("- ")
+			case MatStairsUp:
+				fmt.Print("< ")
+			case MatStairsDown:
+				fmt.Print("> ")
+			case MatChasm:
+				fmt.Print("  ")
 			default:
 				fmt.Print("ER")
 			}
@@ -425,12 +486,24 @@ var (
 // Generate generates a new dungeon with the given width and height.
 // Further parameters include the number of attempts to use to place rooms,
 // the minimum and maximum room size, and the seed to use.
+// All other options are set to their defaults (see DefaultOptions).
 func Generate(width, height, roomAttempts, minRoomSize, maxRoomSize int, seed int64) *Dungeon {
-	dng := createEmptyDungeon(width, height, seed)
-	dng.createRooms(minRoomSize, maxRoomSize, roomAttempts)
+	opts := DefaultOptions(width, height, seed)
+	opts.RoomAttempts = roomAttempts
+	opts.MinRoomSize = minRoomSize
+	opts.MaxRoomSize = maxRoomSize
+	return GenerateWithOptions(opts)
+}
+
+// GenerateWithOptions generates a new dungeon using the given options.
+func GenerateWithOptions(opts Options) *Dungeon {
+	dng := createEmptyDungeon(opts)
+	dng.createRooms(opts.MinRoomSize, opts.MaxRoomSize, opts.RoomAttempts)
 	dng.createMaze()
 	dng.identifyEdges()
 	dng.connectRegions()
-	dng.trimTunnels()
+	if opts.TrimDeadEnds {
+		dng.trimTunnels()
+	}
 	return dng
 }
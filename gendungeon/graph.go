@@ -0,0 +1,369 @@
+package gendungeon
+
+// NodeKind represents the kind of a node in the dungeon graph.
+type NodeKind int
+
+// The various node kinds.
+const (
+	NodeRoom     NodeKind = iota // a room
+	NodeCorridor                 // a connected set of tunnel tiles
+)
+
+// Node is a room or corridor in the dungeon graph.
+type Node struct {
+	ID    int      // index of the node in Graph.Nodes
+	Kind  NodeKind // room or corridor
+	Room  int      // index of the room in Dungeon.Rooms (-1 for corridors)
+	Tiles []Point  // all tiles belonging to the node
+}
+
+// Center returns the tile of the node closest to its center of mass.
+func (n *Node) Center() Point {
+	if len(n.Tiles) == 0 {
+		return Point{}
+	}
+	var sx, sy int
+	for _, t := range n.Tiles {
+		sx += t.X
+		sy += t.Y
+	}
+	c := Point{X: sx / len(n.Tiles), Y: sy / len(n.Tiles)}
+	best := n.Tiles[0]
+	for _, t := range n.Tiles {
+		if dist2(t, c) < dist2(best, c) {
+			best = t
+		}
+	}
+	return best
+}
+
+// Connection is an edge in the dungeon graph, which is a door connecting
+// two rooms or a room and a corridor.
+type Connection struct {
+	ID   int   // index of the connection in Graph.Connections
+	A, B int   // IDs of the connected nodes
+	Door Point // position of the door
+}
+
+// Other returns the node ID on the other side of the connection.
+func (c *Connection) Other(id int) int {
+	if c.A == id {
+		return c.B
+	}
+	return c.A
+}
+
+// Graph is the connectivity graph of a dungeon with rooms and corridors
+// as nodes and doors as edges.
+type Graph struct {
+	Nodes       []*Node
+	Connections []*Connection
+	adj         [][]int // connection IDs by node ID
+	tileNode    [][]int // node ID by tile (-1 for walls and doors)
+}
+
+// Graph extracts the room / corridor graph from the generated tiles.
+func (dng *Dungeon) Graph() *Graph {
+	g := &Graph{
+		tileNode: make([][]int, dng.Height),
+	}
+	for y := range g.tileNode {
+		g.tileNode[y] = make([]int, dng.Width)
+		for x := range g.tileNode[y] {
+			g.tileNode[y][x] = -1
+		}
+	}
+
+	// Add the rooms.
+	for i, r := range dng.Rooms {
+		n := g.addNode(NodeRoom, i)
+		for y := r.Location.Y; y < r.Location.Y+r.Height; y++ {
+			for x := r.Location.X; x < r.Location.X+r.Width; x++ {
+				if !r.IsFloor(x-r.Location.X, y-r.Location.Y) || !dng.isWalkable(x, y) {
+					continue // Non-rectangular rooms and chasms.
+				}
+				n.Tiles = append(n.Tiles, Point{X: x, Y: y})
+				g.tileNode[y][x] = n.ID
+			}
+		}
+	}
+
+	// Add the corridors (connected tunnel tiles).
+	for y := 0; y < dng.Height; y++ {
+		for x := 0; x < dng.Width; x++ {
+			if g.tileNode[y][x] != -1 || !dng.isWalkable(x, y) || dng.Tiles[y][x].Material == MatDoor {
+				continue
+			}
+			n := g.addNode(NodeCorridor, -1)
+			g.floodFill(dng, n, Point{X: x, Y: y})
+		}
+	}
+
+	// Add the doors as connections between the nodes they connect.
+	for y := 0; y < dng.Height; y++ {
+		for x := 0; x < dng.Width; x++ {
+			if dng.Tiles[y][x].Material != MatDoor {
+				continue
+			}
+			var nodes []int
+			for _, nb := range dng.neighbors4(x, y) {
+				if id := g.tileNode[nb.Y][nb.X]; id != -1 && !containsInt(nodes, id) {
+					nodes = append(nodes, id)
+				}
+			}
+			for i := 0; i < len(nodes); i++ {
+				for j := i + 1; j < len(nodes); j++ {
+					g.addConnection(nodes[i], nodes[j], Point{X: x, Y: y})
+				}
+			}
+		}
+	}
+	return g
+}
+
+func (g *Graph) addNode(kind NodeKind, room int) *Node {
+	n := &Node{
+		ID:   len(g.Nodes),
+		Kind: kind,
+		Room: room,
+	}
+	g.Nodes = append(g.Nodes, n)
+	g.adj = append(g.adj, nil)
+	return n
+}
+
+func (g *Graph) addConnection(a, b int, door Point) {
+	c := &Connection{
+		ID:   len(g.Connections),
+		A:    a,
+		B:    b,
+		Door: door,
+	}
+	g.Connections = append(g.Connections, c)
+	g.adj[a] = append(g.adj[a], c.ID)
+	g.adj[b] = append(g.adj[b], c.ID)
+}
+
+// floodFill assigns all tunnel tiles connected to the start to the node.
+func (g *Graph) floodFill(dng *Dungeon, n *Node, start Point) {
+	stack := []Point{start}
+	g.tileNode[start.Y][start.X] = n.ID
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		n.Tiles = append(n.Tiles, p)
+		for _, nb := range dng.neighbors4(p.X, p.Y) {
+			if g.tileNode[nb.Y][nb.X] != -1 || !dng.isWalkable(nb.X, nb.Y) || dng.Tiles[nb.Y][nb.X].Material == MatDoor {
+				continue
+			}
+			g.tileNode[nb.Y][nb.X] = n.ID
+			stack = append(stack, nb)
+		}
+	}
+}
+
+// NodeAt returns the ID of the node at the given tile (-1 for walls,
+// doors and tiles out of bounds).
+func (g *Graph) NodeAt(x, y int) int {
+	if y < 0 || y >= len(g.tileNode) || x < 0 || x >= len(g.tileNode[y]) {
+		return -1
+	}
+	return g.tileNode[y][x]
+}
+
+// ConnectionsOf returns all connections of the given node.
+func (g *Graph) ConnectionsOf(id int) []*Connection {
+	var res []*Connection
+	for _, c := range g.adj[id] {
+		res = append(res, g.Connections[c])
+	}
+	return res
+}
+
+// Neighbors returns the IDs of all nodes connected to the given node.
+func (g *Graph) Neighbors(id int) []int {
+	var res []int
+	for _, c := range g.adj[id] {
+		if o := g.Connections[c].Other(id); !containsInt(res, o) {
+			res = append(res, o)
+		}
+	}
+	return res
+}
+
+// Distances returns the number of connections (doors) that have to be
+// passed to get from the given node to each node (-1 if unreachable).
+func (g *Graph) Distances(from int) []int {
+	dist := make([]int, len(g.Nodes))
+	for i := range dist {
+		dist[i] = -1
+	}
+	if from < 0 || from >= len(g.Nodes) {
+		return dist
+	}
+	dist[from] = 0
+	queue := []int{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, nb := range g.Neighbors(cur) {
+			if dist[nb] == -1 {
+				dist[nb] = dist[cur] + 1
+				queue = append(queue, nb)
+			}
+		}
+	}
+	return dist
+}
+
+// RoomDepths returns for each node the number of rooms that have to be
+// passed to get there from the given node (excluding the start and the
+// node itself; -1 if unreachable).
+func (g *Graph) RoomDepths(from int) []int {
+	depth := make([]int, len(g.Nodes))
+	for i := range depth {
+		depth[i] = -1
+	}
+	if from < 0 || from >= len(g.Nodes) {
+		return depth
+	}
+
+	// Since only rooms add to the depth, we use a 0-1 BFS.
+	depth[from] = 0
+	deque := []int{from}
+	for len(deque) > 0 {
+		cur := deque[0]
+		deque = deque[1:]
+		cost := 0
+		if cur != from && g.Nodes[cur].Kind == NodeRoom {
+			cost = 1
+		}
+		for _, nb := range g.Neighbors(cur) {
+			if d := depth[cur] + cost; depth[nb] == -1 || d < depth[nb] {
+				depth[nb] = d
+				if cost == 0 {
+					deque = append([]int{nb}, deque...)
+				} else {
+					deque = append(deque, nb)
+				}
+			}
+		}
+	}
+	return depth
+}
+
+// ShortestPath returns the node IDs on the shortest path (fewest doors)
+// from one node to another, including both. Returns nil if there is none.
+func (g *Graph) ShortestPath(from, to int) []int {
+	if from < 0 || from >= len(g.Nodes) || to < 0 || to >= len(g.Nodes) {
+		return nil
+	}
+	prev := make([]int, len(g.Nodes))
+	for i := range prev {
+		prev[i] = -1
+	}
+	prev[from] = from
+	queue := []int{from}
+	for len(queue) > 0 && prev[to] == -1 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, nb := range g.Neighbors(cur) {
+			if prev[nb] == -1 {
+				prev[nb] = cur
+				queue = append(queue, nb)
+			}
+		}
+	}
+	if prev[to] == -1 {
+		return nil
+	}
+	var path []int
+	for n := to; n != from; n = prev[n] {
+		path = append(path, n)
+	}
+	path = append(path, from)
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// CriticalPath returns the node IDs on the path from the entrance to the
+// goal, which the player has to traverse to finish the dungeon.
+func (g *Graph) CriticalPath(entrance, goal int) []int {
+	return g.ShortestPath(entrance, goal)
+}
+
+// DeadEnds returns the IDs of all nodes with a single connection.
+func (g *Graph) DeadEnds() []int {
+	var res []int
+	for _, n := range g.Nodes {
+		if len(g.Neighbors(n.ID)) == 1 {
+			res = append(res, n.ID)
+		}
+	}
+	return res
+}
+
+// RoomNode returns the node ID of the room with the given index (-1 if
+// there is none).
+func (g *Graph) RoomNode(room int) int {
+	for _, n := range g.Nodes {
+		if n.Kind == NodeRoom && n.Room == room {
+			return n.ID
+		}
+	}
+	return -1
+}
+
+// FarthestRoom returns the node ID of the room with the largest distance
+// from the given node (-1 if no other room is reachable).
+func (g *Graph) FarthestRoom(from int) int {
+	dist := g.Distances(from)
+	best := -1
+	for _, n := range g.Nodes {
+		if n.Kind != NodeRoom || n.ID == from || dist[n.ID] == -1 {
+			continue
+		}
+		if best == -1 || dist[n.ID] > dist[best] {
+			best = n.ID
+		}
+	}
+	return best
+}
+
+// isWalkable returns true if the tile is within bounds and not a wall or chasm.
+func (dng *Dungeon) isWalkable(x, y int) bool {
+	if x < 0 || x >= dng.Width || y < 0 || y >= dng.Height {
+		return false
+	}
+	m := dng.Tiles[y][x].Material
+	return m != MatWall && m != MatChasm
+}
+
+// neighbors4 returns the orthogonal neighbors of the tile within bounds.
+func (dng *Dungeon) neighbors4(x, y int) []Point {
+	var res []Point
+	for _, nb := range [4]Point{{X: x - 1, Y: y}, {X: x + 1, Y: y}, {X: x, Y: y - 1}, {X: x, Y: y + 1}} {
+		if nb.X >= 0 && nb.X < dng.Width && nb.Y >= 0 && nb.Y < dng.Height {
+			res = append(res, nb)
+		}
+	}
+	return res
+}
+
+// dist2 returns the squared distance between two points.
+func dist2(a, b Point) int {
+	dx, dy := a.X-b.X, a.Y-b.Y
+	return dx*dx + dy*dy
+}
+
+// containsInt returns true if the slice contains the value.
+func containsInt(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package gendungeon
+
+// Lock is a locked door that can only be opened with the key of the same ID.
+type Lock struct {
+	ID   int   // ID of the lock (matches the key)
+	Door Point // position of the locked door
+}
+
+// Key opens the lock with the same ID.
+type Key struct {
+	ID       int   // ID of the key (matches the lock)
+	Position Point // position of the key
+}
+
+// Suggested default value for the number of lock layers.
+var LockLayers = 2
+
+// PlaceLocksAndKeys places the entrance, the exit (boss room), and the
+// given number of locked doors with their keys.
+//
+// The locks are placed on the critical path from the entrance to the exit
+// and the key of each lock is placed in an area reachable with only the
+// keys of the previous locks, so the dungeon is always solvable.
+// If there are fewer suitable doors than requested, fewer locks are placed.
+func (dng *Dungeon) PlaceLocksAndKeys(layers int) {
+	dng.Locks = nil
+	dng.Keys = nil
+	if len(dng.Rooms) == 0 {
+		return
+	}
+	g := dng.Graph()
+
+	// Pick a random room as entrance and the room farthest away as exit.
+	entrance := g.RoomNode(dng.rand.Intn(len(dng.Rooms)))
+	exit := g.FarthestRoom(entrance)
+	if exit == -1 {
+		exit = entrance
+	}
+	dng.Entrance = g.Nodes[entrance].Center()
+	dng.Exit = g.Nodes[exit].Center()
+	dng.placeLocks(g, entrance, exit, layers)
+}
+
+// placeLocks places the given number of locked doors with their keys on
+// the critical path between the entrance and exit nodes.
+func (dng *Dungeon) placeLocks(g *Graph, entrance, exit, layers int) {
+	// Find the doors on the critical path that can't be bypassed.
+	path := g.CriticalPath(entrance, exit)
+	var candidates []Point
+	for i := 0; i+1 < len(path); i++ {
+		door := g.connectionBetween(path[i], path[i+1]).Door
+		blocked := map[Point]bool{door: true}
+		if g.reachable(entrance, blocked)[exit] {
+			continue // There is another way around this door.
+		}
+		candidates = append(candidates, door)
+	}
+	if layers > len(candidates) {
+		layers = len(candidates)
+	}
+	if layers <= 0 {
+		return
+	}
+
+	// Spread the locks evenly along the critical path, with the last one
+	// guarding the exit.
+	for k := 0; k < layers; k++ {
+		dng.Locks = append(dng.Locks, Lock{
+			ID:   k,
+			Door: candidates[((k+1)*len(candidates))/layers-1],
+		})
+	}
+
+	// Place the keys. The key of lock k has to be reachable if all locks
+	// from k on are closed.
+	onPath := make(map[int]bool)
+	for _, n := range path {
+		onPath[n] = true
+	}
+	dist := g.Distances(entrance)
+	hasKey := make(map[int]bool)
+	taken := map[Point]bool{dng.Entrance: true, dng.Exit: true}
+	for k := range dng.Locks {
+		reach := g.reachable(entrance, dng.lockedDoors(k))
+
+		// Prefer the area that has just been unlocked by the previous lock,
+		// nodes off the critical path, and nodes without a key.
+		var prev map[int]bool
+		if k > 0 {
+			prev = g.reachable(entrance, dng.lockedDoors(k-1))
+		}
+		best := -1
+		bestScore := -1
+		for _, n := range dng.rand.Perm(len(g.Nodes)) {
+			if !reach[n] || (n == entrance && len(reach) > 1) {
+				continue
+			}
+			score := dist[n]
+			if !prev[n] {
+				score += len(g.Nodes)
+			}
+			if !onPath[n] {
+				score += 2 * len(g.Nodes)
+			}
+			if !hasKey[n] {
+				score += 4 * len(g.Nodes)
+			}
+			if score > bestScore {
+				best = n
+				bestScore = score
+			}
+		}
+		hasKey[best] = true
+		pos := dng.randomFreeTile(g.Nodes[best], taken)
+		taken[pos] = true
+		dng.Keys = append(dng.Keys, Key{
+			ID:       k,
+			Position: pos,
+		})
+	}
+}
+
+// randomFreeTile returns a random tile of the node that is not taken yet
+// (or the center of the node if all tiles are taken).
+func (dng *Dungeon) randomFreeTile(n *Node, taken map[Point]bool) Point {
+	for _, i := range dng.rand.Perm(len(n.Tiles)) {
+		if !taken[n.Tiles[i]] {
+			return n.Tiles[i]
+		}
+	}
+	return n.Center()
+}
+
+// lockedDoors returns the doors of all locks with an ID >= the given ID.
+func (dng *Dungeon) lockedDoors(from int) map[Point]bool {
+	doors := make(map[Point]bool)
+	for _, l := range dng.Locks {
+		if l.ID >= from {
+			doors[l.Door] = true
+		}
+	}
+	return doors
+}
+
+// connectionBetween returns the first connection between the two nodes.
+func (g *Graph) connectionBetween(a, b int) *Connection {
+	for _, c := range g.ConnectionsOf(a) {
+		if c.Other(a) == b {
+			return c
+		}
+	}
+	return nil
+}
+
+// reachable returns all nodes reachable from the given node without
+// passing through the blocked doors.
+func (g *Graph) reachable(from int, blocked map[Point]bool) map[int]bool {
+	seen := map[int]bool{from: true}
+	queue := []int{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, c := range g.ConnectionsOf(cur) {
+			if o := c.Other(cur); !seen[o] && !blocked[c.Door] {
+				seen[o] = true
+				queue = append(queue, o)
+			}
+		}
+	}
+	return seen
+}
+
+// IsSolvable returns true if the exit can be reached from the entrance by
+// collecting the keys and opening the locked doors.
+func (dng *Dungeon) IsSolvable() bool {
+	locked := make(map[Point]int)
+	for _, l := range dng.Locks {
+		locked[l.Door] = l.ID
+	}
+	keys := make(map[Point][]int)
+	for _, k := range dng.Keys {
+		keys[k.Position] = append(keys[k.Position], k.ID)
+	}
+	owned := make(map[int]bool)
+
+	// Explore until we can't collect any more keys.
+	for {
+		seen := map[Point]bool{dng.Entrance: true}
+		queue := []Point{dng.Entrance}
+		var found bool
+		for len(queue) > 0 {
+			p := queue[0]
+			queue = queue[1:]
+			if p == dng.Exit {
+				return true
+			}
+			for _, id := range keys[p] {
+				if !owned[id] {
+					owned[id] = true
+					found = true
+				}
+			}
+			for _, nb := range dng.neighbors4(p.X, p.Y) {
+				if seen[nb] || !dng.isWalkable(nb.X, nb.Y) {
+					continue
+				}
+				if id, ok := locked[nb]; ok && !owned[id] {
+					continue
+				}
+				seen[nb] = true
+				queue = append(queue, nb)
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package gendungeon
+
+import "testing"
+
+// reachableTiles returns all walkable tiles of the dungeon reachable from the
+// given position without passing through the blocked tiles.
+func reachableTiles(dng *Dungeon, from Point, blocked map[Point]bool) map[Point]bool {
+	seen := map[Point]bool{from: true}
+	queue := []Point{from}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, nb := range dng.neighbors4(p.X, p.Y) {
+			if seen[nb] || blocked[nb] || !dng.isWalkable(nb.X, nb.Y) {
+				continue
+			}
+			seen[nb] = true
+			queue = append(queue, nb)
+		}
+	}
+	return seen
+}
+
+func TestPlaceLocksAndKeys(t *testing.T) {
+	var numLocks int
+	for seed := int64(1); seed <= 50; seed++ {
+		opts := DefaultOptions(50, 50, seed)
+		if seed%2 == 0 {
+			opts.ExtraConnectionChance = 0.05
+		}
+		dng := GenerateWithOptions(opts)
+		dng.PlaceLocksAndKeys(3)
+		numLocks += len(dng.Locks)
+
+		if !dng.IsSolvable() {
+			t.Errorf("seed %d: dungeon is not solvable", seed)
+		}
+		if len(dng.Keys) != len(dng.Locks) {
+			t.Errorf("seed %d: got %d keys for %d locks", seed, len(dng.Keys), len(dng.Locks))
+			continue
+		}
+
+		// Each key has to be reachable without passing through its own
+		// lock or any of the following locks.
+		for _, k := range dng.Keys {
+			reach := reachableTiles(dng, dng.Entrance, dng.lockedDoors(k.ID))
+			if !reach[k.Position] {
+				t.Errorf("seed %d: key %d at %v is behind its own lock", seed, k.ID, k.Position)
+			}
+		}
+
+		// The exit has to be behind all locks.
+		if len(dng.Locks) > 0 {
+			if reach := reachableTiles(dng, dng.Entrance, dng.lockedDoors(0)); reach[dng.Exit] {
+				t.Errorf("seed %d: exit is reachable without opening any lock", seed)
+			}
+		}
+	}
+	if numLocks == 0 {
+		t.Error("no locks were placed")
+	}
+}
@@ -0,0 +1,236 @@
+package gendungeon
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/Flokey82/go_gens/gencellular"
+)
+
+// Logger is used to report the progress of the generation.
+// *log.Logger satisfies this interface.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Options contains the parameters for the dungeon generation.
+type Options struct {
+	Width                 int         // width of the dungeon
+	Height                int         // height of the dungeon
+	Seed                  int64       // seed for the random number generator
+	RoomAttempts          int         // number of attempts to place rooms
+	MinRoomSize           int         // minimum room width and height
+	MaxRoomSize           int         // maximum room width and height
+	ExtraConnectionChance float64     // chance (0.0-1.0) that a room edge becomes an additional door (creates loops)
+	WindingPercent        int         // chance (0-100) that a maze changes direction (100 is fully random)
+	TrimDeadEnds          bool        // remove dead ends of the mazes
+	RoomShapes            []RoomShape // shapes to pick from for each room (nil for rectangles only)
+	FixedRooms            []Room      // rooms that are placed before the random rooms (must not overlap)
+	Logger                Logger      // logger for progress messages (nil for silent operation)
+}
+
+// DefaultOptions returns the suggested default options for a dungeon with
+// the given size and seed.
+func DefaultOptions(width, height int, seed int64) Options {
+	return Options{
+		Width:          width,
+		Height:         height,
+		Seed:           seed,
+		RoomAttempts:   RoomAttempts,
+		MinRoomSize:    MinRoomSize,
+		MaxRoomSize:    MaxRoomSize,
+		WindingPercent: 100,
+		TrimDeadEnds:   true,
+	}
+}
+
+// logf reports progress if a logger is set.
+func (dng *Dungeon) logf(format string, v ...interface{}) {
+	if dng.opts.Logger != nil {
+		dng.opts.Logger.Printf(format, v...)
+	}
+}
+
+// RoomShape returns the floor mask ([y][x]) for a room with the given
+// width and height. A nil mask represents a rectangular room.
+type RoomShape func(rng *rand.Rand, width, height int) [][]bool
+
+// newMask returns an empty mask with the given dimensions.
+func newMask(width, height int) [][]bool {
+	mask := make([][]bool, height)
+	for y := range mask {
+		mask[y] = make([]bool, width)
+	}
+	return mask
+}
+
+// ShapeRectangle is a plain rectangular room.
+func ShapeRectangle(rng *rand.Rand, width, height int) [][]bool {
+	return nil
+}
+
+// ShapeCircle is a circular (or elliptical) room.
+func ShapeCircle(rng *rand.Rand, width, height int) [][]bool {
+	mask := newMask(width, height)
+	rx, ry := float64(width)/2, float64(height)/2
+	for y := range mask {
+		for x := range mask[y] {
+			dx := (float64(x) + 0.5 - rx) / rx
+			dy := (float64(y) + 0.5 - ry) / ry
+			mask[y][x] = dx*dx+dy*dy <= 1
+		}
+	}
+	return mask
+}
+
+// ShapeCross is a room in the shape of a plus sign.
+func ShapeCross(rng *rand.Rand, width, height int) [][]bool {
+	mask := newMask(width, height)
+	bw := int(math.Max(1, float64(width)/3))
+	bh := int(math.Max(1, float64(height)/3))
+	for y := range mask {
+		for x := range mask[y] {
+			inV := x >= bw && x < width-bw
+			inH := y >= bh && y < height-bh
+			mask[y][x] = inV || inH
+		}
+	}
+	return mask
+}
+
+// ShapeCave is an irregular cave blob generated with a cellular automaton.
+func ShapeCave(rng *rand.Rand, width, height int) [][]bool {
+	const ticks = 4
+	c := gencellular.NewCustom(height, width, func(cells [][]bool, w, h int) {
+		for x := 0; x < w; x++ {
+			for y := 0; y < h; y++ {
+				cells[x][y] = rng.Intn(100) < 55
+			}
+		}
+	}, func(alive bool, n int) bool {
+		// Cave rule: born with 5+ neighbors, survive with 4+ neighbors.
+		return n >= 5 || (alive && n >= 4)
+	})
+	for i := 0; i < ticks; i++ {
+		c.Tick()
+	}
+	cells := c.Cells[c.Generation%2]
+
+	// Keep the largest connected blob, so the cave is not split up.
+	mask := newMask(width, height)
+	seen := newMask(width, height)
+	var best []Point
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if seen[y][x] || !cells[x][y] {
+				continue
+			}
+			var blob []Point
+			stack := []Point{{X: x, Y: y}}
+			seen[y][x] = true
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				blob = append(blob, p)
+				for _, nb := range [4]Point{{X: p.X - 1, Y: p.Y}, {X: p.X + 1, Y: p.Y}, {X: p.X, Y: p.Y - 1}, {X: p.X, Y: p.Y + 1}} {
+					if nb.X < 0 || nb.Y < 0 || nb.X >= width || nb.Y >= height || seen[nb.Y][nb.X] || !cells[nb.X][nb.Y] {
+						continue
+					}
+					seen[nb.Y][nb.X] = true
+					stack = append(stack, nb)
+				}
+			}
+			if len(blob) > len(best) {
+				best = blob
+			}
+		}
+	}
+
+	// If the cave collapsed, fall back to a circle.
+	if len(best) < width*height/4 {
+		return ShapeCircle(rng, width, height)
+	}
+	for _, p := range best {
+		mask[p.Y][p.X] = true
+	}
+	return mask
+}
+
+// roomMask picks a random shape from the options and returns the mask for
+// a room with the given dimensions.
+func (dng *Dungeon) roomMask(width, height int) [][]bool {
+	shapes := dng.opts.RoomShapes
+	switch len(shapes) {
+	case 0:
+		return nil
+	case 1:
+		return shapes[0](dng.rand, width, height)
+	}
+	return shapes[dng.rand.Intn(len(shapes))](dng.rand, width, height)
+}
+
+// roomRegion returns the region of the given room.
+func (dng *Dungeon) roomRegion(r *Room) int {
+	for y := 0; y < r.Height; y++ {
+		for x := 0; x < r.Width; x++ {
+			if r.IsFloor(x, y) {
+				return dng.Tiles[r.Location.Y+y][r.Location.X+x].Region
+			}
+		}
+	}
+	return 0
+}
+
+// identifyShapedEdges identifies the edges of a non-rectangular room, which
+// are wall tiles next to the room that border on a tunnel or another room.
+func (dng *Dungeon) identifyShapedEdges(r *Room) {
+	seen := make(map[Point]bool)
+	for y := 0; y < r.Height; y++ {
+		for x := 0; x < r.Width; x++ {
+			if !r.IsFloor(x, y) {
+				continue
+			}
+			px, py := r.Location.X+x, r.Location.Y+y
+			for _, d := range [4]Point{{X: 0, Y: -1}, {X: 0, Y: 1}, {X: -1, Y: 0}, {X: 1, Y: 0}} {
+				edge := Point{X: px + d.X, Y: py + d.Y}
+				beyond := Point{X: px + 2*d.X, Y: py + 2*d.Y}
+				if seen[edge] || !dng.inBounds(beyond.X, beyond.Y) ||
+					dng.Tiles[edge.Y][edge.X].Material != MatWall ||
+					r.IsFloor(beyond.X-r.Location.X, beyond.Y-r.Location.Y) {
+					continue
+				}
+				if m := dng.Tiles[beyond.Y][beyond.X].Material; m == MatTunnel || m == MatFloor {
+					seen[edge] = true
+					r.Edges = append(r.Edges, edge)
+				}
+			}
+		}
+	}
+}
+
+// addExtraConnections turns random room edges into doors, which creates
+// loops in the otherwise tree-like dungeon.
+func (dng *Dungeon) addExtraConnections() {
+	for _, r := range dng.Rooms {
+		for _, e := range r.Edges {
+			if dng.Tiles[e.Y][e.X].Material != MatWall || dng.rand.Float64() >= dng.opts.ExtraConnectionChance {
+				continue
+			}
+			// Avoid placing doors right next to each other.
+			var nextToDoor bool
+			for _, nb := range dng.neighbors4(e.X, e.Y) {
+				if dng.Tiles[nb.Y][nb.X].Material == MatDoor {
+					nextToDoor = true
+				}
+			}
+			if !nextToDoor {
+				dng.Tiles[e.Y][e.X].Material = MatDoor
+			}
+		}
+	}
+}
+
+// inBounds returns true if the given tile is within the dungeon.
+func (dng *Dungeon) inBounds(x, y int) bool {
+	return x >= 0 && x < dng.Width && y >= 0 && y < dng.Height
+}
@@ -0,0 +1,88 @@
+package genflora
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Flokey82/go_gens/genbiome"
+)
+
+// Habitat describes the environmental conditions at a location, for example
+// provided by a map generator.
+type Habitat struct {
+	Temperature   float64 // Avg. yearly temperature (in °C).
+	Precipitation float64 // Avg. yearly precipitation (in mm).
+	Shade         float64 // Shade cast by the surrounding vegetation (0.0 none - 1.0 full).
+	Slope         float64 // Steepness of the terrain (0.0 flat - 1.0 vertical).
+}
+
+// BiomeHabitat returns the typical habitat of the given biome.
+func BiomeHabitat(b genbiome.Biome) Habitat {
+	t, p := b.Climate()
+	return Habitat{
+		Temperature:   t,
+		Precipitation: p * 10, // cm to mm
+		Shade:         b.VegetationDensity(),
+	}
+}
+
+// Biome returns the Whittaker biome of the habitat.
+func (h Habitat) Biome() genbiome.Biome {
+	return genbiome.Whittaker(h.Temperature, h.Precipitation/10)
+}
+
+// Parameters for the fitness calculation.
+var (
+	TemperatureTolerance = 15.0   // Temperature deviation (in °C) reducing the fitness to ~37%.
+	WaterPerRequirement  = 5000.0 // Precipitation (in mm) per unit of water requirement.
+	CanopyHeight         = 20.0   // Height (in m) above which plants don't suffer from shade.
+	SlopeHeight          = 30.0   // Height (in m) at which plants can't survive on vertical slopes.
+)
+
+// Fitness returns the fitness (0.0 - 1.0) of the plant lineage in the given
+// habitat.
+// NOTE: This is experimental and depends on the equally experimental water
+// and temperature requirements.
+func (p PlantLineage) Fitness(h Habitat) float64 {
+	// Deviations from the optimal temperature reduce the fitness.
+	dt := (h.Temperature - p.getTemperatureRequirement()) / TemperatureTolerance
+	fitness := math.Exp(-dt * dt)
+
+	// Plants that don't get enough water don't thrive.
+	if need := p.getWaterRequirement() * WaterPerRequirement; need > h.Precipitation {
+		fitness *= math.Max(0, h.Precipitation) / need
+	}
+
+	// Small plants suffer in the shade of larger plants.
+	fitness *= 1 - h.Shade*(1-math.Min(1, p.MaxHeight/CanopyHeight))
+
+	// Tall plants struggle to anchor on steep slopes.
+	fitness *= 1 - math.Min(1, h.Slope*p.MaxHeight/SlopeHeight)
+	return math.Max(0, fitness)
+}
+
+// SelectLineages returns up to n plant lineages with the highest fitness in
+// the given habitat, ignoring lineages that can't survive at all.
+func SelectLineages(lineages []*PlantLineage, h Habitat, n int) []*PlantLineage {
+	type candidate struct {
+		lineage *PlantLineage
+		fitness float64
+	}
+	var candidates []candidate
+	for _, l := range lineages {
+		if f := l.Fitness(h); f > 0 {
+			candidates = append(candidates, candidate{l, f})
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].fitness > candidates[b].fitness
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	res := make([]*PlantLineage, len(candidates))
+	for i, c := range candidates {
+		res[i] = c.lineage
+	}
+	return res
+}
@@ -0,0 +1,82 @@
+package genheightmap
+
+import (
+	"math"
+	"sort"
+)
+
+// gradient returns the gradient at the given coordinates using central
+// differences.
+func (h *Heightmap) gradient(x, y int) (float64, float64) {
+	dx := (h.At(x+1, y) - h.At(x-1, y)) / 2
+	dy := (h.At(x, y+1) - h.At(x, y-1)) / 2
+	return dx, dy
+}
+
+// Slope returns a heightmap containing the steepness of each cell (the length
+// of the gradient in height units per cell).
+func (h *Heightmap) Slope() *Heightmap {
+	s := NewHeightmap(h.Width, h.Height)
+	for i := range h.Values {
+		dx, dy := h.gradient(h.Coordinates(i))
+		s.Values[i] = math.Hypot(dx, dy)
+	}
+	return s
+}
+
+// Aspect returns a heightmap containing the direction each cell is facing
+// (the direction of the steepest descent) in radians, where 0 is facing
+// east (positive x) and Pi/2 is facing south (positive y).
+// Flat cells have an aspect of 0.
+func (h *Heightmap) Aspect() *Heightmap {
+	a := NewHeightmap(h.Width, h.Height)
+	for i := range h.Values {
+		dx, dy := h.gradient(h.Coordinates(i))
+		if dx != 0 || dy != 0 {
+			a.Values[i] = math.Atan2(-dy, -dx)
+		}
+	}
+	return a
+}
+
+// Curvature returns a heightmap containing the curvature (the laplacian) of
+// each cell. Positive values indicate concave cells (valleys), negative values
+// indicate convex cells (ridges, peaks).
+func (h *Heightmap) Curvature() *Heightmap {
+	c := NewHeightmap(h.Width, h.Height)
+	for i, v := range h.Values {
+		x, y := h.Coordinates(i)
+		c.Values[i] = h.At(x+1, y) + h.At(x-1, y) + h.At(x, y+1) + h.At(x, y-1) - 4*v
+	}
+	return c
+}
+
+// FlowAccumulation returns a heightmap containing the number of cells that
+// drain through each cell (including the cell itself), where each cell drains
+// to its lowest neighbor (D8). High values indicate rivers.
+func (h *Heightmap) FlowAccumulation() *Heightmap {
+	f := NewHeightmap(h.Width, h.Height)
+
+	// Process the cells from the highest to the lowest, so that the flow of
+	// all upstream cells has been accumulated before it is passed on.
+	order := make([]int, len(h.Values))
+	for i := range order {
+		order[i] = i
+		f.Values[i] = 1
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return h.Values[order[a]] > h.Values[order[b]]
+	})
+	for _, i := range order {
+		down := -1
+		for _, nb := range h.Neighbors(i) {
+			if h.Values[nb] < h.Values[i] && (down == -1 || h.Values[nb] < h.Values[down]) {
+				down = nb
+			}
+		}
+		if down != -1 {
+			f.Values[down] += f.Values[i]
+		}
+	}
+	return f
+}
@@ -0,0 +1,130 @@
+package genheightmap
+
+import (
+	"math"
+)
+
+// GaussianBlur blurs the heightmap using a gaussian kernel with the given
+// standard deviation (in cells).
+func (h *Heightmap) GaussianBlur(sigma float64) *Heightmap {
+	if sigma <= 0 {
+		return h
+	}
+
+	// Set up the kernel, which covers three standard deviations.
+	radius := int(math.Ceil(sigma * 3))
+	kernel := make([]float64, 2*radius+1)
+	var sum float64
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	// The gaussian blur is separable, so we blur horizontally first and
+	// then vertically.
+	tmp := make([]float64, len(h.Values))
+	for y := 0; y < h.Height; y++ {
+		for x := 0; x < h.Width; x++ {
+			var val float64
+			for i, k := range kernel {
+				val += h.At(x+i-radius, y) * k
+			}
+			tmp[h.Index(x, y)] = val
+		}
+	}
+	for y := 0; y < h.Height; y++ {
+		for x := 0; x < h.Width; x++ {
+			var val float64
+			for i, k := range kernel {
+				val += tmp[h.Index(x, clampInt(y+i-radius, 0, h.Height-1))] * k
+			}
+			h.Values[h.Index(x, y)] = val
+		}
+	}
+	return h
+}
+
+// Sharpen sharpens the heightmap using an unsharp mask, which exaggerates the
+// difference between each value and the blurred heightmap by 'amount'.
+func (h *Heightmap) Sharpen(sigma, amount float64) *Heightmap {
+	blurred := h.Copy().GaussianBlur(sigma)
+	for i, v := range h.Values {
+		h.Values[i] = v + (v-blurred.Values[i])*amount
+	}
+	return h
+}
+
+// Terrace quantizes the heightmap into the given number of terraces
+// (see ModTerrace).
+func (h *Heightmap) Terrace(steps int, smoothness float64) *Heightmap {
+	min, max := h.MinMax()
+	if min == max {
+		return h
+	}
+	t := ModTerrace(steps, smoothness)
+	return h.MapF(func(val float64) float64 {
+		return min + t((val-min)/(max-min))*(max-min)
+	})
+}
+
+// ThermalErosion simulates the collapse of steep slopes, where material moves
+// from a cell to its lower neighbors if the height difference exceeds 'talus'.
+// 'amount' (0.0-1.0) is the fraction of the excess material that is moved
+// per iteration.
+func (h *Heightmap) ThermalErosion(iterations int, talus, amount float64) *Heightmap {
+	delta := make([]float64, len(h.Values))
+	moved := func(i int, excess float64) float64 {
+		return amount * excess / 2
+	}
+	for it := 0; it < iterations; it++ {
+		ThermalErosionDelta(h.Values, delta, h.Neighbors, talus, moved)
+		for i, d := range delta {
+			h.Values[i] += d
+		}
+	}
+	return h
+}
+
+// ThermalErosionDelta computes one iteration of thermal erosion for the given
+// height values and writes the resulting change in height of each cell to
+// 'delta' (which must have the same length as 'values').
+//
+// For each cell with lower neighbors more than 'talus' below it, 'moved'
+// returns the amount of material that collapses given the excess height over
+// the steepest of these neighbors. The material is distributed to the lower
+// neighbors proportionally to the height difference.
+//
+// NOTE: All changes are collected in 'delta' before they are applied, so the
+// result does not depend on the order in which the cells are processed.
+func ThermalErosionDelta(values, delta []float64, neighbors func(i int) []int, talus float64, moved func(i int, excess float64) float64) {
+	for i := range delta {
+		delta[i] = 0
+	}
+	for i, v := range values {
+		// Find the total excess height over all lower neighbors.
+		var total, maxDiff float64
+		nbs := neighbors(i)
+		for _, nb := range nbs {
+			if d := v - values[nb]; d > talus {
+				total += d
+				maxDiff = math.Max(maxDiff, d)
+			}
+		}
+		if total == 0 {
+			continue
+		}
+
+		// Distribute the material proportionally to the height difference.
+		m := moved(i, maxDiff-talus)
+		for _, nb := range nbs {
+			if d := v - values[nb]; d > talus {
+				delta[nb] += m * d / total
+			}
+		}
+		delta[i] -= m
+	}
+}
@@ -50,16 +50,16 @@ func GenVolCone(slope float64) GenFunc {
 // GenMountains returns a generator function that will return the height of a
 // point on the heightmap given the point's coordinates, which will produce a
 // number of mountains.
-// TODO: The seed should be passed into the function as parameter.
 //
 // 'maxX', 'maxY' are the dimensions of the heightmap.
 // 'n' is the number of mountains.
 // 'r' is the radius of the mountains.
-func GenMountains(maxX, maxY float64, n int, r float64) GenFunc {
-	rand.Seed(1234)
+// 'seed' is the seed used to place the mountains.
+func GenMountains(maxX, maxY float64, n int, r float64, seed int64) GenFunc {
+	rng := rand.New(rand.NewSource(seed))
 	var mounts [][2]float64
 	for i := 0; i < n; i++ {
-		mounts = append(mounts, [2]float64{maxX * (rand.Float64() - 0.5), maxY * (rand.Float64() - 0.5)})
+		mounts = append(mounts, [2]float64{maxX * (rng.Float64() - 0.5), maxY * (rng.Float64() - 0.5)})
 	}
 	return func(x, y float64) float64 {
 		var val float64
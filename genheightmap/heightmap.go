@@ -0,0 +1,163 @@
+package genheightmap
+
+import (
+	"math"
+)
+
+// Heightmap is a grid based heightmap implementing the Terrain interface.
+type Heightmap struct {
+	Width  int
+	Height int
+	Values []float64 // height values, row by row (see Index)
+}
+
+// NewHeightmap returns a new, flat heightmap with the given dimensions.
+func NewHeightmap(width, height int) *Heightmap {
+	return &Heightmap{
+		Width:  width,
+		Height: height,
+		Values: make([]float64, width*height),
+	}
+}
+
+// Copy returns a copy of the heightmap.
+func (h *Heightmap) Copy() *Heightmap {
+	c := NewHeightmap(h.Width, h.Height)
+	copy(c.Values, h.Values)
+	return c
+}
+
+// Index returns the index of the given coordinates.
+func (h *Heightmap) Index(x, y int) int {
+	return x + y*h.Width
+}
+
+// Coordinates returns the coordinates of the given index.
+func (h *Heightmap) Coordinates(idx int) (int, int) {
+	return idx % h.Width, idx / h.Width
+}
+
+// InBounds returns true if the given coordinates are within the heightmap.
+func (h *Heightmap) InBounds(x, y int) bool {
+	return x >= 0 && x < h.Width && y >= 0 && y < h.Height
+}
+
+// At returns the height at the given coordinates. Coordinates outside of the
+// heightmap are clamped to the border.
+func (h *Heightmap) At(x, y int) float64 {
+	return h.Values[h.Index(clampInt(x, 0, h.Width-1), clampInt(y, 0, h.Height-1))]
+}
+
+// Set sets the height at the given coordinates.
+func (h *Heightmap) Set(x, y int, val float64) {
+	h.Values[h.Index(x, y)] = val
+}
+
+// Sample returns the bilinearly interpolated height at the given (fractional)
+// coordinates.
+func (h *Heightmap) Sample(x, y float64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	tx, ty := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	a := h.At(ix, iy)*(1-tx) + h.At(ix+1, iy)*tx
+	b := h.At(ix, iy+1)*(1-tx) + h.At(ix+1, iy+1)*tx
+	return a*(1-ty) + b*ty
+}
+
+// Neighbors returns the indices of the (up to 8) neighbors of the given index.
+func (h *Heightmap) Neighbors(idx int) []int {
+	x, y := h.Coordinates(idx)
+	var nbs []int
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if (dx != 0 || dy != 0) && h.InBounds(x+dx, y+dy) {
+				nbs = append(nbs, h.Index(x+dx, y+dy))
+			}
+		}
+	}
+	return nbs
+}
+
+// MinMax returns the min and max values of the heightmap.
+func (h *Heightmap) MinMax() (float64, float64) {
+	return MinMax(h.Values)
+}
+
+// ApplyGen adds the values of the given generator function to the heightmap.
+// The generator is sampled in the range [-0.5, 0.5] along the longer side of
+// the heightmap, centered on the heightmap.
+func (h *Heightmap) ApplyGen(f GenFunc) *Heightmap {
+	s := float64(maxInt(h.Width, h.Height))
+	for i := range h.Values {
+		x, y := h.Coordinates(i)
+		h.Values[i] += f((float64(x)-float64(h.Width)/2)/s, (float64(y)-float64(h.Height)/2)/s)
+	}
+	return h
+}
+
+// MapF applies the given modifier to all values of the heightmap.
+func (h *Heightmap) MapF(f Modify) *Heightmap {
+	for i, v := range h.Values {
+		h.Values[i] = f(v)
+	}
+	return h
+}
+
+// MapFWithIndex applies the given modifier to all values of the heightmap.
+// The modifier reads the original values, so it can depend on the values of
+// the neighbors (see ModRelax).
+func (h *Heightmap) MapFWithIndex(f ModifyWithIndex) *Heightmap {
+	vals := make([]float64, len(h.Values))
+	for i, v := range h.Values {
+		vals[i] = f(i, v)
+	}
+	h.Values = vals
+	return h
+}
+
+// Normalize normalizes the heightmap to the range [0, 1].
+func (h *Heightmap) Normalize() *Heightmap {
+	min, max := h.MinMax()
+	if min == max {
+		return h.MapF(func(float64) float64 { return 0 })
+	}
+	return h.MapF(ModNormalize(min, max))
+}
+
+// Relax averages each value with its neighbors.
+func (h *Heightmap) Relax() *Heightmap {
+	return h.MapFWithIndex(ModRelax(h.Neighbors, func(idx int) float64 {
+		return h.Values[idx]
+	}))
+}
+
+// Resample returns a copy of the heightmap resampled to the given dimensions
+// using bilinear interpolation.
+func (h *Heightmap) Resample(width, height int) *Heightmap {
+	r := NewHeightmap(width, height)
+	sx := float64(h.Width-1) / math.Max(1, float64(width-1))
+	sy := float64(h.Height-1) / math.Max(1, float64(height-1))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r.Values[r.Index(x, y)] = h.Sample(float64(x)*sx, float64(y)*sy)
+		}
+	}
+	return r
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
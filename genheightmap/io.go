@@ -0,0 +1,98 @@
+package genheightmap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+)
+
+// to16Bit returns the heightmap normalized to 16 bit values (rounded to the
+// nearest value). A flat heightmap results in all zeros.
+func (h *Heightmap) to16Bit() []uint16 {
+	min, max := h.MinMax()
+	vals := make([]uint16, len(h.Values))
+	if min == max {
+		return vals
+	}
+	for i, v := range h.Values {
+		vals[i] = uint16(math.Round((v - min) / (max - min) * 65535))
+	}
+	return vals
+}
+
+// ExportPNG16 normalizes the heightmap and exports it as 16 bit grayscale PNG.
+func (h *Heightmap) ExportPNG16(path string) error {
+	img := image.NewGray16(image.Rect(0, 0, h.Width, h.Height))
+	for i, v := range h.to16Bit() {
+		x, y := h.Coordinates(i)
+		img.SetGray16(x, y, color.Gray16{Y: v})
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ImportPNG16 imports the heightmap from a grayscale PNG. The values are in
+// the range [0, 1].
+// NOTE: 8 bit and color images are supported as well.
+func ImportPNG16(path string) (*Heightmap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	h := NewHeightmap(b.Dx(), b.Dy())
+	for y := 0; y < h.Height; y++ {
+		for x := 0; x < h.Width; x++ {
+			c := color.Gray16Model.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray16)
+			h.Values[h.Index(x, y)] = float64(c.Y) / 65535
+		}
+	}
+	return h, nil
+}
+
+// ExportRAW normalizes the heightmap and exports it as headerless 16 bit
+// little endian RAW file (.raw / .r16), as used by many terrain tools.
+func (h *Heightmap) ExportRAW(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := binary.Write(f, binary.LittleEndian, h.to16Bit()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ImportRAW imports the heightmap with the given dimensions from a headerless
+// 16 bit little endian RAW file. The values are in the range [0, 1].
+func ImportRAW(path string, width, height int) (*Heightmap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != width*height*2 {
+		return nil, fmt.Errorf("unexpected file size %d for %dx%d heightmap", len(data), width, height)
+	}
+	h := NewHeightmap(width, height)
+	for i := range h.Values {
+		h.Values[i] = float64(binary.LittleEndian.Uint16(data[i*2:])) / 65535
+	}
+	return h, nil
+}
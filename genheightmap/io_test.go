@@ -0,0 +1,94 @@
+package genheightmap
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+// testHeightmap returns a non-square heightmap with random values.
+func testHeightmap() *Heightmap {
+	rng := rand.New(rand.NewSource(1234))
+	h := NewHeightmap(37, 23)
+	for i := range h.Values {
+		h.Values[i] = rng.Float64()*200 - 50
+	}
+	return h
+}
+
+// compareNormalized checks if the imported heightmap matches the normalized
+// values of the original heightmap within the 16 bit precision (half a step,
+// since the values are rounded).
+func compareNormalized(t *testing.T, want, got *Heightmap) {
+	t.Helper()
+	if got.Width != want.Width || got.Height != want.Height {
+		t.Fatalf("got %dx%d heightmap, want %dx%d", got.Width, got.Height, want.Width, want.Height)
+	}
+	min, max := want.MinMax()
+	for i, v := range want.Values {
+		n := (v - min) / (max - min)
+		if d := math.Abs(got.Values[i] - n); d > 0.5/65535+1e-12 {
+			t.Fatalf("value %d: got %f, want %f (diff %g)", i, got.Values[i], n, d)
+		}
+	}
+}
+
+func TestTo16Bit(t *testing.T) {
+	h := testHeightmap()
+	min, max := h.MinMax()
+	for i, v := range h.to16Bit() {
+		switch h.Values[i] {
+		case min:
+			if v != 0 {
+				t.Errorf("minimum: got %d, want 0", v)
+			}
+		case max:
+			if v != 65535 {
+				t.Errorf("maximum: got %d, want 65535", v)
+			}
+		}
+	}
+
+	// A flat heightmap can't be normalized and results in all zeros.
+	flat := NewHeightmap(4, 4)
+	for i := range flat.Values {
+		flat.Values[i] = 42
+	}
+	for i, v := range flat.to16Bit() {
+		if v != 0 {
+			t.Fatalf("flat heightmap: value %d is %d, want 0", i, v)
+		}
+	}
+}
+
+func TestRAWRoundTrip(t *testing.T) {
+	h := testHeightmap()
+	path := filepath.Join(t.TempDir(), "heightmap.r16")
+	if err := h.ExportRAW(path); err != nil {
+		t.Fatalf("ExportRAW: %v", err)
+	}
+	got, err := ImportRAW(path, h.Width, h.Height)
+	if err != nil {
+		t.Fatalf("ImportRAW: %v", err)
+	}
+	compareNormalized(t, h, got)
+
+	// The dimensions have to match the file size.
+	if _, err := ImportRAW(path, h.Width+1, h.Height); err == nil {
+		t.Error("ImportRAW: expected error for wrong size")
+	}
+}
+
+func TestPNG16RoundTrip(t *testing.T) {
+	h := testHeightmap()
+	path := filepath.Join(t.TempDir(), "heightmap.png")
+	if err := h.ExportPNG16(path); err != nil {
+		t.Fatalf("ExportPNG16: %v", err)
+	}
+	got, err := ImportPNG16(path)
+	if err != nil {
+		t.Fatalf("ImportPNG16: %v", err)
+	}
+	compareNormalized(t, h, got)
+}
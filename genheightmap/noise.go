@@ -0,0 +1,262 @@
+package genheightmap
+
+import (
+	"math"
+
+	opensimplex "github.com/ojrac/opensimplex-go"
+)
+
+// octaveOffset is added to the coordinates of each octave, so that the
+// octaves don't line up at the origin.
+const octaveOffset = 31.7
+
+// GenFBm returns a generator function for fractal Brownian motion, which sums
+// up 'octaves' layers of opensimplex noise, starting at the given frequency.
+// The frequency of each octave is multiplied by 'lacunarity' and the amplitude
+// by 'gain'. The result is in the range [-1, 1].
+func GenFBm(seed int64, octaves int, frequency, lacunarity, gain float64) GenFunc {
+	noise := opensimplex.New(seed)
+	return func(x, y float64) float64 {
+		var sum, norm float64
+		amp, freq := 1.0, frequency
+		for i := 0; i < octaves; i++ {
+			off := float64(i) * octaveOffset
+			sum += noise.Eval2(x*freq+off, y*freq+off) * amp
+			norm += amp
+			amp *= gain
+			freq *= lacunarity
+		}
+		return sum / norm
+	}
+}
+
+// GenBillow returns a generator function for billow noise, which is fBm using
+// the absolute value of the noise, resulting in puffy, rounded features.
+// The result is in the range [-1, 1].
+func GenBillow(seed int64, octaves int, frequency, lacunarity, gain float64) GenFunc {
+	noise := opensimplex.New(seed)
+	return func(x, y float64) float64 {
+		var sum, norm float64
+		amp, freq := 1.0, frequency
+		for i := 0; i < octaves; i++ {
+			off := float64(i) * octaveOffset
+			sum += (2*math.Abs(noise.Eval2(x*freq+off, y*freq+off)) - 1) * amp
+			norm += amp
+			amp *= gain
+			freq *= lacunarity
+		}
+		return sum / norm
+	}
+}
+
+// GenRidged returns a generator function for ridged multifractal noise, which
+// produces sharp ridges like mountain ranges. The detail of each octave is
+// weighted by the previous octave, so valleys stay smooth while the ridges
+// get rough. The result is in the range [0, 1].
+// See: "Texturing and Modeling: A Procedural Approach" by Musgrave et al.
+func GenRidged(seed int64, octaves int, frequency, lacunarity, gain float64) GenFunc {
+	noise := opensimplex.New(seed)
+	return func(x, y float64) float64 {
+		var sum, norm float64
+		amp, freq, weight := 1.0, frequency, 1.0
+		for i := 0; i < octaves; i++ {
+			off := float64(i) * octaveOffset
+			signal := 1 - math.Abs(noise.Eval2(x*freq+off, y*freq+off))
+			signal *= signal * weight
+			weight = math.Max(0, math.Min(1, signal*2))
+			sum += signal * amp
+			norm += amp
+			amp *= gain
+			freq *= lacunarity
+		}
+		return sum / norm
+	}
+}
+
+// WorleyMode determines the value returned by Worley noise.
+type WorleyMode int
+
+// The Worley noise modes.
+const (
+	WorleyF1      WorleyMode = iota // distance to the closest feature point
+	WorleyF2                        // distance to the second closest feature point
+	WorleyF2MinF1                   // difference between F2 and F1 (cell borders)
+)
+
+// GenWorley returns a generator function for Worley (cellular) noise with one
+// randomly placed feature point per grid cell of size 1/frequency. The result
+// is the distance (in grid cells) according to the given mode.
+func GenWorley(seed int64, frequency float64, mode WorleyMode) GenFunc {
+	return func(x, y float64) float64 {
+		x *= frequency
+		y *= frequency
+		cx, cy := int64(math.Floor(x)), int64(math.Floor(y))
+		f1, f2 := math.Inf(1), math.Inf(1)
+		for nx := cx - 1; nx <= cx+1; nx++ {
+			for ny := cy - 1; ny <= cy+1; ny++ {
+				px, py := worleyPoint(seed, nx, ny)
+				d := math.Hypot(float64(nx)+px-x, float64(ny)+py-y)
+				if d < f1 {
+					f1, f2 = d, f1
+				} else if d < f2 {
+					f2 = d
+				}
+			}
+		}
+		switch mode {
+		case WorleyF2:
+			return f2
+		case WorleyF2MinF1:
+			return f2 - f1
+		default:
+			return f1
+		}
+	}
+}
+
+// worleyPoint returns the position of the feature point within the given
+// grid cell (in the range [0, 1)).
+func worleyPoint(seed, x, y int64) (float64, float64) {
+	h := uint64(seed) ^ uint64(x)*0x9E3779B97F4A7C15 ^ uint64(y)*0xC2B2AE3D27D4EB4F
+	h = splitmix64(h)
+	px := float64(h>>11) / (1 << 53)
+	h = splitmix64(h)
+	py := float64(h>>11) / (1 << 53)
+	return px, py
+}
+
+// splitmix64 scrambles the bits of the given value.
+// See: https://prng.di.unimi.it/splitmix64.c
+func splitmix64(h uint64) uint64 {
+	h += 0x9E3779B97F4A7C15
+	h = (h ^ (h >> 30)) * 0xBF58476D1CE4E5B9
+	h = (h ^ (h >> 27)) * 0x94D049BB133111EB
+	return h ^ (h >> 31)
+}
+
+// GenDomainWarp returns a generator function that samples f at coordinates
+// displaced by the generators warpX and warpY multiplied by 'strength'.
+// This distorts the features of f, resulting in more organic shapes.
+func GenDomainWarp(f, warpX, warpY GenFunc, strength float64) GenFunc {
+	return func(x, y float64) float64 {
+		return f(x+warpX(x, y)*strength, y+warpY(x, y)*strength)
+	}
+}
+
+// GenFBmWarp returns a generator function that samples f with the coordinates
+// displaced by two fBm noise functions derived from the given seed.
+func GenFBmWarp(f GenFunc, seed int64, frequency, strength float64) GenFunc {
+	return GenDomainWarp(f, GenFBm(seed, 4, frequency, 2, 0.5), GenFBm(seed+1, 4, frequency, 2, 0.5), strength)
+}
+
+// GenConst returns a generator function that always returns the given value.
+func GenConst(val float64) GenFunc {
+	return func(x, y float64) float64 {
+		return val
+	}
+}
+
+// GenAdd returns a generator function that returns the sum of all given
+// generator functions.
+func GenAdd(fs ...GenFunc) GenFunc {
+	return func(x, y float64) float64 {
+		var val float64
+		for _, f := range fs {
+			val += f(x, y)
+		}
+		return val
+	}
+}
+
+// GenMul returns a generator function that returns the product of all given
+// generator functions.
+func GenMul(fs ...GenFunc) GenFunc {
+	return func(x, y float64) float64 {
+		val := 1.0
+		for _, f := range fs {
+			val *= f(x, y)
+		}
+		return val
+	}
+}
+
+// GenScale returns a generator function that multiplies the result of f by
+// the given factor.
+func GenScale(f GenFunc, factor float64) GenFunc {
+	return func(x, y float64) float64 {
+		return f(x, y) * factor
+	}
+}
+
+// GenSelect returns a generator function that returns the value of 'a' where
+// the control function is below the threshold and the value of 'b' otherwise.
+// Within 'falloff' of the threshold, both values are blended smoothly.
+func GenSelect(control, a, b GenFunc, threshold, falloff float64) GenFunc {
+	return func(x, y float64) float64 {
+		c := control(x, y)
+		if falloff <= 0 {
+			if c < threshold {
+				return a(x, y)
+			}
+			return b(x, y)
+		}
+		t := (c - (threshold - falloff)) / (2 * falloff)
+		if t <= 0 {
+			return a(x, y)
+		}
+		if t >= 1 {
+			return b(x, y)
+		}
+		t = t * t * (3 - 2*t) // smoothstep
+		return a(x, y)*(1-t) + b(x, y)*t
+	}
+}
+
+// GenModify returns a generator function that applies the given modifiers
+// to the result of f.
+func GenModify(f GenFunc, mods ...Modify) GenFunc {
+	return func(x, y float64) float64 {
+		val := f(x, y)
+		for _, mod := range mods {
+			val = mod(val)
+		}
+		return val
+	}
+}
+
+// GenClamp returns a generator function that clamps the result of f to the
+// range [min, max].
+func GenClamp(f GenFunc, min, max float64) GenFunc {
+	return GenModify(f, ModClamp(min, max))
+}
+
+// GenTerrace returns a generator function that quantizes the result of f into
+// terraces (see ModTerrace).
+func GenTerrace(f GenFunc, steps int, smoothness float64) GenFunc {
+	return GenModify(f, ModTerrace(steps, smoothness))
+}
+
+// ModClamp clamps the value to the range [min, max].
+func ModClamp(min, max float64) Modify {
+	return func(val float64) float64 {
+		return math.Max(min, math.Min(max, val))
+	}
+}
+
+// ModTerrace quantizes the value (expected to be in the range [0, 1]) into the
+// given number of steps. A smoothness of 0 results in flat terraces with hard
+// edges, a smoothness of 1 in a smooth transition between the terraces.
+func ModTerrace(steps int, smoothness float64) Modify {
+	n := float64(steps)
+	return func(val float64) float64 {
+		v := val * n
+		step := math.Floor(v)
+		t := v - step
+		if smoothness < 1 {
+			// Only transition to the next step within the last part of the step.
+			t = math.Max(0, (t-(1-smoothness))/math.Max(smoothness, 1e-9))
+			t = t * t * (3 - 2*t) // smoothstep
+		}
+		return (step + t) / n
+	}
+}
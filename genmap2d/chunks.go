@@ -0,0 +1,216 @@
+package genmap2d
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+
+	opensimplex "github.com/ojrac/opensimplex-go"
+)
+
+// ChunkGenerator generates an infinite map in chunks. Each chunk is generated
+// deterministically from the seed in world coordinates, so adjacent chunks
+// line up seamlessly and can be generated in any order.
+//
+// Villages are placed per village cell (a square region of the world) using
+// the suitability score of the Village site kind, so a village is always found at the same
+// position no matter which chunk is generated first.
+type ChunkGenerator struct {
+	Seed            int64         // seed of the world
+	ChunkWidth      int           // width of a chunk in cells
+	ChunkHeight     int           // height of a chunk in cells
+	Scale           float64       // cells per noise unit (larger values result in larger features)
+	LatitudeHeight  float64       // cells from the coldest to the warmest latitude
+	Climate         ClimateParams // parameters of the temperature and moisture generation
+	Village         *SiteKind     // site kind placed in the village cells
+	VillageCellSize int           // size of a village cell in cells (0 disables villages)
+	VillageChance   float64       // chance (0.0-1.0) that a village cell contains a village
+	MaxCachedChunks int           // maximum number of chunks kept in the cache (0 for no limit)
+
+	noise    opensimplex.Noise
+	mu       sync.Mutex
+	chunks   map[[2]int]*Map
+	order    [][2]int         // chunk coordinates in the order they were cached
+	villages map[[2]int]*Site // village (in world coordinates) by village cell of the cached chunks (nil if there is none)
+}
+
+// NewChunkGenerator returns a new chunk generator for the given seed and
+// chunk dimensions.
+func NewChunkGenerator(seed int64, chunkWidth, chunkHeight int) *ChunkGenerator {
+	return &ChunkGenerator{
+		Seed:            seed,
+		ChunkWidth:      chunkWidth,
+		ChunkHeight:     chunkHeight,
+		Scale:           128,
+		LatitudeHeight:  512,
+		Climate:         DefaultClimateParams,
+		Village:         SiteVillage,
+		VillageCellSize: 64,
+		VillageChance:   0.5,
+		MaxCachedChunks: 64,
+		noise:           opensimplex.New(seed),
+		chunks:          make(map[[2]int]*Map),
+		villages:        make(map[[2]int]*Site),
+	}
+}
+
+// Chunk returns the chunk at the given chunk coordinates. The coordinates of
+// the cells within the chunk are relative to its top left corner, which is
+// at (cx*ChunkWidth, cy*ChunkHeight) in world coordinates.
+func (g *ChunkGenerator) Chunk(cx, cy int) *Map {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := [2]int{cx, cy}
+	if m, ok := g.chunks[key]; ok {
+		return m
+	}
+	m := g.genChunk(cx, cy)
+
+	// Cache the chunk and evict the oldest chunk if the cache is full.
+	g.chunks[key] = m
+	g.order = append(g.order, key)
+	if g.MaxCachedChunks > 0 && len(g.order) > g.MaxCachedChunks {
+		g.evictChunk(g.order[0])
+		g.order = g.order[1:]
+	}
+	return m
+}
+
+// evictChunk removes the given chunk from the cache along with the village
+// cells that are not overlapped by any other cached chunk.
+func (g *ChunkGenerator) evictChunk(key [2]int) {
+	delete(g.chunks, key)
+	if g.VillageCellSize <= 0 {
+		return
+	}
+	minX, minY, maxX, maxY := g.villageCells(key[0], key[1])
+	for vx := minX; vx <= maxX; vx++ {
+		for vy := minY; vy <= maxY; vy++ {
+			if !g.villageCellCached(vx, vy) {
+				delete(g.villages, [2]int{vx, vy})
+			}
+		}
+	}
+}
+
+// villageCellCached returns true if the given village cell overlaps any of
+// the cached chunks.
+func (g *ChunkGenerator) villageCellCached(vx, vy int) bool {
+	for key := range g.chunks {
+		minX, minY, maxX, maxY := g.villageCells(key[0], key[1])
+		if vx >= minX && vx <= maxX && vy >= minY && vy <= maxY {
+			return true
+		}
+	}
+	return false
+}
+
+// villageCells returns the range of village cells (inclusive) overlapping
+// the given chunk.
+func (g *ChunkGenerator) villageCells(cx, cy int) (minX, minY, maxX, maxY int) {
+	x, y := cx*g.ChunkWidth, cy*g.ChunkHeight
+	minX, minY = floorDiv(x, g.VillageCellSize), floorDiv(y, g.VillageCellSize)
+	maxX = floorDiv(x+g.ChunkWidth-1, g.VillageCellSize)
+	maxY = floorDiv(y+g.ChunkHeight-1, g.VillageCellSize)
+	return minX, minY, maxX, maxY
+}
+
+// ChunksAround returns the chunks within the given radius (in chunks) around
+// the given chunk, starting at the top left.
+func (g *ChunkGenerator) ChunksAround(cx, cy, radius int) []*Map {
+	var res []*Map
+	for y := cy - radius; y <= cy+radius; y++ {
+		for x := cx - radius; x <= cx+radius; x++ {
+			res = append(res, g.Chunk(x, y))
+		}
+	}
+	return res
+}
+
+// ChunkAt returns the chunk coordinates for the given world coordinates.
+func (g *ChunkGenerator) ChunkAt(x, y int) (int, int) {
+	return floorDiv(x, g.ChunkWidth), floorDiv(y, g.ChunkHeight)
+}
+
+// TileAt returns the tile ID at the given world coordinates.
+func (g *ChunkGenerator) TileAt(x, y int) byte {
+	cx, cy := g.ChunkAt(x, y)
+	m := g.Chunk(cx, cy)
+	return m.Cells[m.GetIndex(x-m.OffsetX, y-m.OffsetY)]
+}
+
+// Region generates the given region in world coordinates (without villages).
+func (g *ChunkGenerator) Region(x, y, width, height int) *Map {
+	m := newMap(width, height, rand.New(rand.NewSource(g.Seed^hash2(x, y))), g.noise, g.Climate)
+	m.OffsetX = x
+	m.OffsetY = y
+	m.scale = g.Scale
+	m.latitudeHeight = g.LatitudeHeight
+	m.generate()
+	return m
+}
+
+// genChunk generates the chunk at the given chunk coordinates.
+func (g *ChunkGenerator) genChunk(cx, cy int) *Map {
+	m := g.Region(cx*g.ChunkWidth, cy*g.ChunkHeight, g.ChunkWidth, g.ChunkHeight)
+	if g.VillageCellSize <= 0 {
+		return m
+	}
+
+	// Add the villages of all village cells overlapping the chunk.
+	minX, minY, maxX, maxY := g.villageCells(cx, cy)
+	for vx := minX; vx <= maxX; vx++ {
+		for vy := minY; vy <= maxY; vy++ {
+			v := g.village(vx, vy)
+			if v == nil {
+				continue
+			}
+			x, y := v.X-m.OffsetX, v.Y-m.OffsetY
+			if x < 0 || x >= m.Width || y < 0 || y >= m.Height {
+				continue
+			}
+			s := &Site{Kind: v.Kind, X: x, Y: y, Score: v.Score}
+			m.Sites = append(m.Sites, s)
+			m.Villages = append(m.Villages, s)
+			m.Cells[m.GetIndex(x, y)] = v.Kind.Tile
+		}
+	}
+	return m
+}
+
+// village returns the village (in world coordinates) of the given village
+// cell, or nil if there is none.
+func (g *ChunkGenerator) village(vx, vy int) *Site {
+	key := [2]int{vx, vy}
+	if v, ok := g.villages[key]; ok {
+		return v
+	}
+	var best *Site
+	r := rand.New(rand.NewSource(g.Seed ^ hash2(vx, vy)))
+	if r.Float64() < g.VillageChance {
+		// Find the most suitable location within the village cell.
+		m := g.Region(vx*g.VillageCellSize, vy*g.VillageCellSize, g.VillageCellSize, g.VillageCellSize)
+		for x := 0; x < m.Width; x++ {
+			for y := 0; y < m.Height; y++ {
+				if score, ok := g.Village.Score(m, x, y); ok && (best == nil || score > best.Score) {
+					best = &Site{Kind: g.Village, X: m.OffsetX + x, Y: m.OffsetY + y, Score: score}
+				}
+			}
+		}
+	}
+	g.villages[key] = best
+	return best
+}
+
+// floorDiv returns a / b rounded towards negative infinity.
+func floorDiv(a, b int) int {
+	return int(math.Floor(float64(a) / float64(b)))
+}
+
+// hash2 returns a hash for the given coordinates.
+// See: https://stackoverflow.com/a/37221804
+func hash2(x, y int) int64 {
+	h := x*374761393 + y*668265263 // all constants are prime
+	h = (h ^ (h >> 13)) * 1274126177
+	return int64(h ^ (h >> 16))
+}
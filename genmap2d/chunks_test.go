@@ -0,0 +1,81 @@
+package genmap2d
+
+import (
+	"reflect"
+	"testing"
+)
+
+// compareRegion reports the cells of the region r (in world coordinates)
+// that differ from the given chunk. Villages are skipped, since regions do
+// not contain any.
+func compareRegion(t *testing.T, r, c *Map) {
+	t.Helper()
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			ri := r.GetIndex(c.OffsetX-r.OffsetX+x, c.OffsetY-r.OffsetY+y)
+			ci := c.GetIndex(x, y)
+			if r.Elevation[ri] != c.Elevation[ci] || r.Temperature[ri] != c.Temperature[ci] || r.Moisture[ri] != c.Moisture[ci] {
+				t.Fatalf("chunk at %d,%d: cell %d,%d differs from region", c.OffsetX, c.OffsetY, x, y)
+			}
+			if c.Cells[ci] != TileIDVillage && r.Cells[ri] != c.Cells[ci] {
+				t.Fatalf("chunk at %d,%d: tile %d,%d is %d, region has %d", c.OffsetX, c.OffsetY, x, y, c.Cells[ci], r.Cells[ri])
+			}
+		}
+	}
+}
+
+func TestRegionSpansChunks(t *testing.T) {
+	g := NewChunkGenerator(7, 32, 32)
+
+	// The region spans the border between the chunks (-1, 0) and (0, 0).
+	r := g.Region(-32, 0, 64, 32)
+	compareRegion(t, r, g.Chunk(-1, 0))
+	compareRegion(t, r, g.Chunk(0, 0))
+}
+
+func TestChunkOrder(t *testing.T) {
+	// The rows close to y = 0 are too cold for villages, so we use warmer
+	// rows further south.
+	var chunks [][2]int
+	for cy := 3; cy < 9; cy++ {
+		for cx := -3; cx < 3; cx++ {
+			chunks = append(chunks, [2]int{cx, cy})
+		}
+	}
+	a := NewChunkGenerator(7, 64, 64)
+	b := NewChunkGenerator(7, 64, 64)
+	var numVillages int
+	for i := range chunks {
+		ca := a.Chunk(chunks[i][0], chunks[i][1])
+		cb := b.Chunk(chunks[len(chunks)-1-i][0], chunks[len(chunks)-1-i][1])
+		numVillages += len(ca.Villages) + len(cb.Villages)
+	}
+	if numVillages == 0 {
+		t.Error("no villages placed")
+	}
+	for _, c := range chunks {
+		ca, cb := a.Chunk(c[0], c[1]), b.Chunk(c[0], c[1])
+		if !reflect.DeepEqual(ca.Cells, cb.Cells) || !reflect.DeepEqual(ca.Villages, cb.Villages) {
+			t.Errorf("chunk %d,%d depends on the generation order", c[0], c[1])
+		}
+	}
+}
+
+func TestChunkEviction(t *testing.T) {
+	g := NewChunkGenerator(7, 32, 32)
+	g.MaxCachedChunks = 2
+	first := g.Chunk(0, 8)
+	for cx := 1; cx < 6; cx++ {
+		g.Chunk(cx, 8)
+	}
+	if len(g.chunks) != 2 {
+		t.Fatalf("got %d cached chunks, want 2", len(g.chunks))
+	}
+	again := g.Chunk(0, 8)
+	if again == first {
+		t.Fatal("chunk 0,8 was not evicted")
+	}
+	if !reflect.DeepEqual(first.Cells, again.Cells) || !reflect.DeepEqual(first.Villages, again.Villages) {
+		t.Error("chunk 0,8 changed after eviction")
+	}
+}
@@ -0,0 +1,81 @@
+package genmap2d
+
+import (
+	"math"
+
+	"github.com/Flokey82/go_gens/genbiome"
+)
+
+// ClimateParams contains the parameters for the temperature and moisture
+// generation.
+type ClimateParams struct {
+	TemperatureTop       float64 // average temperature at the top of the map (in °C)
+	TemperatureBottom    float64 // average temperature at the bottom of the map (in °C)
+	TemperatureVariation float64 // maximum temperature deviation through noise (in °C)
+	TemperatureLapseRate float64 // temperature drop per elevation unit above sea level (in °C)
+	PrecipitationMax     float64 // maximum yearly precipitation (in cm)
+	SeaLevel             float64 // elevation of the sea level
+}
+
+// DefaultClimateParams are the default climate parameters.
+var DefaultClimateParams = ClimateParams{
+	TemperatureTop:       -5.0,
+	TemperatureBottom:    30.0,
+	TemperatureVariation: 6.0,
+	TemperatureLapseRate: 0.3,
+	PrecipitationMax:     250.0,
+	SeaLevel:             120.0,
+}
+
+// genClimate generates the temperature and moisture layers and assigns the
+// biomes based on them.
+func (m *Map) genClimate() {
+	cp := &m.Climate
+	for x := 0; x < m.Width; x++ {
+		for y := 0; y < m.Height; y++ {
+			idx := m.GetIndex(x, y)
+			nx, ny := m.noisePos(x, y)
+
+			// The temperature depends on the latitude (the y coordinate) and
+			// drops with the elevation above sea level.
+			t := cp.TemperatureTop + (cp.TemperatureBottom-cp.TemperatureTop)*m.latitude(y)
+			t += m.Noise.Eval2(4*nx+100, 4*ny+100) * cp.TemperatureVariation
+			t -= math.Max(0, m.Elevation[idx]-cp.SeaLevel) * cp.TemperatureLapseRate
+			m.Temperature[idx] = t
+
+			// The moisture is noise based, with some more moisture close to
+			// the sea level.
+			va := m.Noise.Eval2(3*nx-100, 3*ny-100)
+			va += m.Noise.Eval2(8*nx-100, 8*ny-100) * 0.25
+			mo := (va/1.25 + 1) / 2
+			mo += 0.2 * math.Max(0, 1-math.Abs(m.Elevation[idx]-cp.SeaLevel)/20)
+			m.Moisture[idx] = math.Max(0, math.Min(1, mo)) * cp.PrecipitationMax
+
+			m.Biomes[idx] = genbiome.Whittaker(m.Temperature[idx], m.Moisture[idx])
+		}
+	}
+}
+
+// TileFromBiome returns the tile ID for a given biome.
+func (m *Map) TileFromBiome(b genbiome.Biome) byte {
+	switch b {
+	case genbiome.BiomeIce:
+		return TileIDSnow
+	case genbiome.BiomeTundra:
+		return TileIDTundra
+	case genbiome.BiomeBorealForest:
+		return TileIDTaiga
+	case genbiome.BiomeWoodland:
+		return TileIDShrubland
+	case genbiome.BiomeTemperateSeasonalForest, genbiome.BiomeTemperateRainforest:
+		return TileIDTree
+	case genbiome.BiomeSubtropicalDesert:
+		return TileIDDesert
+	case genbiome.BiomeTropicalSeasonalForest:
+		return TileIDSavanna
+	case genbiome.BiomeTropicalRainforest:
+		return TileIDJungle
+	default:
+		return TileIDGrass
+	}
+}
@@ -9,5 +9,9 @@ func main() {
 	for i := 0; i < 5; i++ {
 		v.PlaceVillage()
 	}
+	v.PlaceSite(genmap2d.SiteMine)
+	v.PlaceSite(genmap2d.SitePort)
+	v.PlaceSite(genmap2d.SiteCastle)
+	v.GenRoads()
 	v.ExportPng("rgb.png")
 }
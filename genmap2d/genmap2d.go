@@ -4,9 +4,11 @@ package genmap2d
 import (
 	"image"
 	"image/png"
+	"math"
 	"math/rand"
 	"os"
 
+	"github.com/Flokey82/go_gens/genbiome"
 	opensimplex "github.com/ojrac/opensimplex-go"
 )
 
@@ -15,25 +17,87 @@ type Map struct {
 	Width     int               // Width of the map
 	Height    int               // Height of the map
 	Villages  []*VillageScore   // Generated villages
+	Sites     []*Site           // Generated sites (villages, mines, ...)
+	Roads     []*Road           // Generated roads
 	Elevation []float64         // Elevation values for each cell
 	Cells     []byte            // Cells contains the assigned tile IDs
 	Rand      *rand.Rand        // Rand initialized with the provided seed
 	Noise     opensimplex.Noise // Noise initialized with the provided seed
+
+	Temperature []float64        // Average temperature for each cell (in °C)
+	Moisture    []float64        // Average precipitation for each cell (in cm)
+	Biomes      []genbiome.Biome // Biome for each cell
+	Climate     ClimateParams    // Parameters of the temperature and moisture generation
+
+	OffsetX int // World x coordinate of the top left cell (see ChunkGenerator)
+	OffsetY int // World y coordinate of the top left cell (see ChunkGenerator)
+
+	scale          float64 // cells per noise unit in world coordinates (0 to fit the noise to the map)
+	latitudeHeight float64 // cells from the coldest to the warmest latitude in world coordinates
 }
 
 // New returns a new map with the given dimensions generated using the given seed.
 func New(width, height int, seed int64) *Map {
-	m := &Map{
+	return NewWithClimate(width, height, seed, DefaultClimateParams)
+}
+
+// NewWithClimate returns a new map with the given dimensions generated using
+// the given seed and climate parameters.
+func NewWithClimate(width, height int, seed int64, cp ClimateParams) *Map {
+	m := newMap(width, height, rand.New(rand.NewSource(seed)), opensimplex.New(seed), cp)
+	m.generate()
+	return m
+}
+
+// newMap returns a new, empty map with the given dimensions.
+func newMap(width, height int, r *rand.Rand, noise opensimplex.Noise, cp ClimateParams) *Map {
+	return &Map{
 		Width:     width,
 		Height:    height,
 		Elevation: make([]float64, width*height),
 		Cells:     make([]byte, width*height),
-		Rand:      rand.New(rand.NewSource(seed)),
-		Noise:     opensimplex.New(seed),
+		Rand:      r,
+		Noise:     noise,
+
+		Temperature: make([]float64, width*height),
+		Moisture:    make([]float64, width*height),
+		Biomes:      make([]genbiome.Biome, width*height),
+		Climate:     cp,
 	}
+}
+
+// generate generates the heightmap, climate and tiles.
+func (m *Map) generate() {
 	m.genHeightMap()
+	m.genClimate()
 	m.setup()
-	return m
+}
+
+// noisePos returns the noise coordinates for the given cell. For maps of a
+// fixed size, the noise is fit to the dimensions of the map. For chunks, the
+// world coordinates are used, so adjacent chunks line up seamlessly.
+func (m *Map) noisePos(x, y int) (float64, float64) {
+	if m.scale == 0 {
+		return float64(x) / float64(m.Width), float64(y) / float64(m.Height)
+	}
+	return float64(m.OffsetX+x) / m.scale, float64(m.OffsetY+y) / m.scale
+}
+
+// latitude returns the latitude (0.0 coldest to 1.0 warmest) of the given
+// row. In world coordinates, the latitude alternates between cold and warm
+// every latitudeHeight cells.
+func (m *Map) latitude(y int) float64 {
+	if m.scale == 0 {
+		return float64(y) / float64(m.Height)
+	}
+	p := math.Mod(float64(m.OffsetY+y)/m.latitudeHeight, 2)
+	if p < 0 {
+		p += 2
+	}
+	if p > 1 {
+		return 2 - p
+	}
+	return p
 }
 
 // GetIndex returns the index for the given x and y coordinates.
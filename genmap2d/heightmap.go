@@ -2,13 +2,13 @@ package genmap2d
 
 // genHeightMap generates the heightmap using opensimplex noise.
 func (m *Map) genHeightMap() {
-	w, h := m.Width, m.Height
-	for x := 0; x < w; x++ {
-		for y := 0; y < h; y++ {
-			va := m.Noise.Eval2(2*float64(x)/float64(w), 2*float64(y)/float64(h))
-			va += m.Noise.Eval2(float64(x)/float64(w), float64(y)/float64(h)) * 0.1
-			va += m.Noise.Eval2(3*float64(x)/float64(w), 3*float64(y)/float64(h)) * 0.2
-			va += m.Noise.Eval2(10*float64(x)/float64(w), 10*float64(y)/float64(h)) * 0.05
+	for x := 0; x < m.Width; x++ {
+		for y := 0; y < m.Height; y++ {
+			nx, ny := m.noisePos(x, y)
+			va := m.Noise.Eval2(2*nx, 2*ny)
+			va += m.Noise.Eval2(nx, ny) * 0.1
+			va += m.Noise.Eval2(3*nx, 3*ny) * 0.2
+			va += m.Noise.Eval2(10*nx, 10*ny) * 0.05
 			m.Elevation[m.GetIndex(x, y)] = ((va / 1.35) * 128) + 128
 		}
 	}
@@ -0,0 +1,192 @@
+package genmap2d
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Road is a road connecting two sites.
+type Road struct {
+	From, To *Site
+	Path     []int // cell indices from the start to the end of the road
+}
+
+// RoadCosts contains the cost of building a road through each tile type.
+// Tiles not listed here have a cost of 1.
+var RoadCosts = map[byte]float64{
+	TileIDTree:     3,
+	TileIDTaiga:    3,
+	TileIDJungle:   5,
+	TileIDSand:     1.5,
+	TileIDDesert:   1.5,
+	TileIDMountain: 10,
+	TileIDSnow:     15,
+	TileIDWater:    25,  // bridge (see MaxBridgeLength)
+	TileIDRoad:     0.3, // existing roads are reused
+	TileIDBridge:   0.3,
+}
+
+// RoadSlopeCost is the cost added per unit of elevation change.
+var RoadSlopeCost = 1.0
+
+// MaxBridgeLength is the maximum number of consecutive water cells a road can
+// cross. Since TileIDWater covers both the sea and lakes (there are no rivers),
+// this keeps roads from bridging open water.
+var MaxBridgeLength = 6
+
+// GenRoads connects all villages with a road network. The villages are
+// connected along a minimum spanning tree, where each road follows the
+// least-cost path. Since existing roads are cheap to follow, the roads merge
+// into a network.
+func (m *Map) GenRoads() {
+	if len(m.Villages) < 2 {
+		return
+	}
+
+	// Build the minimum spanning tree using Prim's algorithm.
+	connected := make([]bool, len(m.Villages))
+	connected[0] = true
+	for n := 1; n < len(m.Villages); n++ {
+		bestFrom, bestTo, bestDist := -1, -1, math.MaxInt64
+		for i, a := range m.Villages {
+			if !connected[i] {
+				continue
+			}
+			for j, b := range m.Villages {
+				if d := dist(a.X, a.Y, b.X, b.Y); !connected[j] && d < bestDist {
+					bestFrom, bestTo, bestDist = i, j, d
+				}
+			}
+		}
+		connected[bestTo] = true
+		m.AddRoad(m.Villages[bestFrom], m.Villages[bestTo])
+	}
+}
+
+// AddRoad builds a road along the least-cost path between the two sites,
+// marks it on the map and returns it (nil if there is no path).
+// Water tiles along the road become bridges (see MaxBridgeLength).
+func (m *Map) AddRoad(from, to *Site) *Road {
+	path := m.roadPath(m.GetIndex(from.X, from.Y), m.GetIndex(to.X, to.Y))
+	if path == nil {
+		return nil
+	}
+	for _, idx := range path {
+		switch m.Cells[idx] {
+		case TileIDWater:
+			m.Cells[idx] = TileIDBridge
+		case TileIDVillage, TileIDMine, TileIDPort, TileIDCastle, TileIDBridge:
+			// Keep sites and bridges.
+		default:
+			m.Cells[idx] = TileIDRoad
+		}
+	}
+	r := &Road{From: from, To: to, Path: path}
+	m.Roads = append(m.Roads, r)
+	return r
+}
+
+// roadCost returns the cost of building a road from cell a to the
+// neighboring cell b.
+func (m *Map) roadCost(a, b int) float64 {
+	c, ok := RoadCosts[m.Cells[b]]
+	if !ok {
+		c = 1
+	}
+	ax, ay := m.GetCoordinates(a)
+	bx, by := m.GetCoordinates(b)
+	if ax != bx && ay != by {
+		c *= math.Sqrt2
+	}
+	return c + math.Abs(m.Elevation[b]-m.Elevation[a])*RoadSlopeCost
+}
+
+// isBridgeable returns true if a road crossing the tile becomes a bridge.
+func isBridgeable(t byte) bool {
+	return t == TileIDWater || t == TileIDBridge
+}
+
+// roadPath returns the least-cost path from start to end using Dijkstra's
+// algorithm (nil if there is none). Paths crossing more than MaxBridgeLength
+// consecutive water cells are not considered.
+func (m *Map) roadPath(start, end int) []int {
+	// Each state is a cell and the number of consecutive water cells crossed
+	// to reach it, so the bridge length can be limited.
+	runs := MaxBridgeLength + 1
+	if runs < 1 {
+		runs = 1
+	}
+	cost := make([]float64, len(m.Cells)*runs)
+	prev := make([]int, len(m.Cells)*runs)
+	for i := range cost {
+		cost[i] = math.Inf(1)
+		prev[i] = -1
+	}
+	cost[start*runs] = 0
+	last := -1
+	queue := &roadQueue{{idx: start}}
+	for queue.Len() > 0 {
+		cur := heap.Pop(queue).(roadQueueEntry)
+		if cur.idx == end {
+			last = cur.idx*runs + cur.run
+			break
+		}
+		if cur.cost > cost[cur.idx*runs+cur.run] {
+			continue // Outdated entry.
+		}
+		x, y := m.GetCoordinates(cur.idx)
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				nx, ny := x+dx, y+dy
+				if (dx == 0 && dy == 0) || nx < 0 || nx >= m.Width || ny < 0 || ny >= m.Height {
+					continue
+				}
+				nb := m.GetIndex(nx, ny)
+				var run int
+				if isBridgeable(m.Cells[nb]) {
+					if run = cur.run + 1; run >= runs {
+						continue // Bridge would be too long.
+					}
+				}
+				ns := nb*runs + run
+				if c := cur.cost + m.roadCost(cur.idx, nb); c < cost[ns] {
+					cost[ns] = c
+					prev[ns] = cur.idx*runs + cur.run
+					heap.Push(queue, roadQueueEntry{idx: nb, run: run, cost: c})
+				}
+			}
+		}
+	}
+	if last == -1 {
+		return nil
+	}
+	var path []int
+	for s := last; s != -1; s = prev[s] {
+		path = append(path, s/runs)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+type roadQueueEntry struct {
+	idx  int
+	run  int // consecutive water cells crossed to reach idx
+	cost float64
+}
+
+// roadQueue is a priority queue returning the entry with the lowest cost.
+type roadQueue []roadQueueEntry
+
+func (q roadQueue) Len() int            { return len(q) }
+func (q roadQueue) Less(i, j int) bool  { return q[i].cost < q[j].cost }
+func (q roadQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *roadQueue) Push(x interface{}) { *q = append(*q, x.(roadQueueEntry)) }
+func (q *roadQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	*q = old[:n-1]
+	return e
+}
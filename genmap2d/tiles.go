@@ -13,6 +13,17 @@ const (
 	TileIDMountain
 	TileIDSnow
 	TileIDVillage
+	TileIDDesert
+	TileIDTundra
+	TileIDTaiga
+	TileIDShrubland
+	TileIDSavanna
+	TileIDJungle
+	TileIDMine
+	TileIDPort
+	TileIDCastle
+	TileIDRoad
+	TileIDBridge
 	TileIDMax
 )
 
@@ -53,15 +64,42 @@ func (m *Map) TileColor(tID byte) color.Color {
 		return color.RGBA{0xFF, 0xFF, 0xFF, 0xff}
 	case TileIDVillage:
 		return color.RGBA{0xFF, 0x00, 0x00, 0xff}
+	case TileIDDesert:
+		return color.RGBA{0xE0, 0xC8, 0x8C, 0xff}
+	case TileIDTundra:
+		return color.RGBA{0x9C, 0xA8, 0x8C, 0xff}
+	case TileIDTaiga:
+		return color.RGBA{0x2C, 0x50, 0x3C, 0xff}
+	case TileIDShrubland:
+		return color.RGBA{0x88, 0x99, 0x4C, 0xff}
+	case TileIDSavanna:
+		return color.RGBA{0xA8, 0xA8, 0x40, 0xff}
+	case TileIDJungle:
+		return color.RGBA{0x1C, 0x5C, 0x1C, 0xff}
+	case TileIDMine:
+		return color.RGBA{0x40, 0x20, 0x00, 0xff}
+	case TileIDPort:
+		return color.RGBA{0x00, 0x00, 0xFF, 0xff}
+	case TileIDCastle:
+		return color.RGBA{0xFF, 0x00, 0xFF, 0xff}
+	case TileIDRoad:
+		return color.RGBA{0x8B, 0x6B, 0x3D, 0xff}
+	case TileIDBridge:
+		return color.RGBA{0x5C, 0x40, 0x20, 0xff}
 	default:
 		return color.RGBA{0x00, 0x00, 0x00, 0xff}
 	}
 }
 
-// setup assigns all tiles based on the height of a given point.
+// setup assigns all tiles based on the height and biome of a given point.
 func (m *Map) setup() {
 	m.run(func(x, y int) byte {
-		return m.TileFromHeight(int(m.Elevation[m.GetIndex(x, y)]) - 120)
+		idx := m.GetIndex(x, y)
+		switch t := m.TileFromHeight(int(m.Elevation[idx] - m.Climate.SeaLevel)); t {
+		case TileIDWater, TileIDSand, TileIDMountain, TileIDSnow:
+			return t
+		}
+		return m.TileFromBiome(m.Biomes[idx])
 	})
 }
 
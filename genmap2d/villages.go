@@ -5,47 +5,223 @@ import (
 	"sort"
 )
 
-type VillageScore struct {
+// Site is a placed site (village, mine, ...) with its suitability score.
+type Site struct {
+	Kind  *SiteKind
 	X, Y  int
 	Score float64
 }
 
+// VillageScore is a placed village.
+// NOTE: This is an alias for Site for compatibility.
+type VillageScore = Site
+
+// SuitabilityFunc returns the suitability score of the given location for a
+// site and false if the location is not suitable at all.
+type SuitabilityFunc func(m *Map, x, y int) (float64, bool)
+
+// SiteKind defines a kind of site with its own placement criteria.
+type SiteKind struct {
+	Name    string          // name of the site kind
+	Tile    byte            // tile ID used to mark the site on the map
+	Score   SuitabilityFunc // suitability of a location
+	Spacing float64         // penalty weight for being close to other sites of the same kind
+}
+
+// The default site kinds.
+var (
+	SiteVillage = &SiteKind{
+		Name:    "village",
+		Tile:    TileIDVillage,
+		Score:   ScoreVillageHabitable,
+		Spacing: 0.02,
+	}
+	SiteMine = &SiteKind{
+		Name:    "mine",
+		Tile:    TileIDMine,
+		Score:   ScoreMine,
+		Spacing: 0.02,
+	}
+	SitePort = &SiteKind{
+		Name:    "port",
+		Tile:    TileIDPort,
+		Score:   ScorePort,
+		Spacing: 0.05,
+	}
+	SiteCastle = &SiteKind{
+		Name:    "castle",
+		Tile:    TileIDCastle,
+		Score:   ScoreCastle,
+		Spacing: 0.05,
+	}
+)
+
+// ScoreVillage prefers grass land with trees and water nearby.
+// NOTE: Grass requires a dry temperate climate (see TileFromBiome), so with
+// the default climate parameters it is rare. SiteVillage uses
+// ScoreVillageHabitable instead.
+func ScoreVillage(m *Map, x, y int) (float64, bool) {
+	if m.Cells[m.GetIndex(x, y)] != TileIDGrass {
+		return 0, false
+	}
+	return scoreVillageSurroundings(m, x, y), true
+}
+
+// ScoreVillageHabitable is like ScoreVillage, but accepts all habitable land
+// (grass, shrubland and savanna). This is the default for SiteVillage.
+func ScoreVillageHabitable(m *Map, x, y int) (float64, bool) {
+	if !isHabitable(m.Cells[m.GetIndex(x, y)]) {
+		return 0, false
+	}
+	return scoreVillageSurroundings(m, x, y), true
+}
+
+// scoreVillageSurroundings returns the village score for the trees and water
+// around the given location.
+func scoreVillageSurroundings(m *Map, x, y int) float64 {
+	var score float64
+	for _, cr := range m.tilesInRadius(x, y, 20) {
+		switch cr {
+		case TileIDTree, TileIDTaiga, TileIDJungle:
+			score += 0.01
+		case TileIDWater:
+			score += 0.01
+		}
+	}
+	return score
+}
+
+// ScoreMine prefers land at the foot of the mountains.
+func ScoreMine(m *Map, x, y int) (float64, bool) {
+	if !isLand(m.Cells[m.GetIndex(x, y)]) {
+		return 0, false
+	}
+	var score float64
+	for _, cr := range m.tilesInRadius(x, y, 6) {
+		switch cr {
+		case TileIDMountain:
+			score += 0.02
+		case TileIDSnow:
+			score += 0.01
+		}
+	}
+	return score, score > 0
+}
+
+// ScorePort prefers the coast with lots of open water and villages nearby.
+func ScorePort(m *Map, x, y int) (float64, bool) {
+	if !isLand(m.Cells[m.GetIndex(x, y)]) || !m.hasNeighbor(x, y, TileIDWater) {
+		return 0, false
+	}
+	var score float64
+	for _, cr := range m.tilesInRadius(x, y, 10) {
+		if cr == TileIDWater {
+			score += 0.01
+		}
+	}
+	for _, v := range m.Villages {
+		score += 0.1 / (float64(dist(v.X, v.Y, x, y)) + 1)
+	}
+	return score, true
+}
+
+// ScoreCastle prefers elevated habitable land overlooking its surroundings,
+// close to villages.
+func ScoreCastle(m *Map, x, y int) (float64, bool) {
+	if !isHabitable(m.Cells[m.GetIndex(x, y)]) {
+		return 0, false
+	}
+	// Compare the elevation with the average elevation of the surroundings.
+	var sum float64
+	var n int
+	for cx := x - 8; cx <= x+8; cx++ {
+		for cy := y - 8; cy <= y+8; cy++ {
+			if cx < 0 || cx >= m.Width || cy < 0 || cy >= m.Height {
+				continue
+			}
+			sum += m.Elevation[m.GetIndex(cx, cy)]
+			n++
+		}
+	}
+	score := (m.Elevation[m.GetIndex(x, y)] - sum/float64(n)) * 0.05
+	for _, v := range m.Villages {
+		score += 0.1 / (float64(dist(v.X, v.Y, x, y)) + 1)
+	}
+	return score, true
+}
+
+// isHabitable returns true if the tile is suitable for settlements.
+func isHabitable(t byte) bool {
+	switch t {
+	case TileIDGrass, TileIDShrubland, TileIDSavanna:
+		return true
+	}
+	return false
+}
+
+// isLand returns true if the tile is free land.
+func isLand(t byte) bool {
+	switch t {
+	case TileIDWater, TileIDSnow, TileIDMountain, TileIDVillage, TileIDMine, TileIDPort, TileIDCastle, TileIDBridge:
+		return false
+	}
+	return true
+}
+
+// hasNeighbor returns true if any of the 8 neighbors has the given tile ID.
+func (m *Map) hasNeighbor(x, y int, t byte) bool {
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			nx, ny := x+dx, y+dy
+			if (dx != 0 || dy != 0) && nx >= 0 && nx < m.Width && ny >= 0 && ny < m.Height && m.Cells[m.GetIndex(nx, ny)] == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // PlaceVillage calculates the suitability score for each point on the map and
 // will add a new village that gets the optimal score while being as far as possible
 // from other villages.
-// TODO: Factor out suitability function.
 func (m *Map) PlaceVillage() {
+	if s := m.PlaceSite(SiteVillage); s != nil {
+		m.Villages = append(m.Villages, s)
+	}
+}
+
+// PlaceSite calculates the suitability score for each point on the map using
+// the scoring function of the given site kind and adds a new site at the
+// location with the optimal score while being as far as possible from other
+// sites of the same kind. Returns nil if there is no suitable location.
+func (m *Map) PlaceSite(kind *SiteKind) *Site {
 	d := math.Sqrt(float64(m.Width*m.Width + m.Height*m.Height))
-	var scores []*VillageScore
+	var scores []*Site
 	for x := 0; x < m.Width; x++ {
 		for y := 0; y < m.Height; y++ {
-			if m.Cells[m.GetIndex(x, y)] != TileIDGrass {
+			score, ok := kind.Score(m, x, y)
+			if !ok {
 				continue
 			}
-			ns := &VillageScore{X: x, Y: y}
-			for _, cr := range m.tilesInRadius(x, y, 20) {
-				switch cr {
-				case TileIDTree:
-					ns.Score += 0.01
-				case TileIDWater:
-					ns.Score += 0.01
+			ns := &Site{Kind: kind, X: x, Y: y, Score: score}
+			for _, other := range m.Sites {
+				if other.Kind == kind {
+					ns.Score -= float64(kind.Spacing / (float64(dist(other.X, other.Y, ns.X, ns.Y))/d + 1e-9))
 				}
 			}
-			for _, other := range m.Villages {
-				ns.Score -= float64(0.02 / (float64(dist(other.X, other.Y, ns.X, ns.Y))/d + 1e-9))
-			}
 			scores = append(scores, ns)
 		}
 	}
 	if len(scores) == 0 {
-		return
+		return nil
 	}
 	sort.Slice(scores, func(a, b int) bool {
 		return scores[a].Score > scores[b].Score
 	})
 	winner := scores[0]
-	m.Villages = append(m.Villages, winner)
-	m.Cells[m.GetIndex(winner.X, winner.Y)] = TileIDVillage
+	m.Sites = append(m.Sites, winner)
+	m.Cells[m.GetIndex(winner.X, winner.Y)] = kind.Tile
+	return winner
 }
 
 // dist calculates the distance between two points.
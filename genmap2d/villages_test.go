@@ -0,0 +1,20 @@
+package genmap2d
+
+import "testing"
+
+func TestPlaceVillage(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3, 42, 1234} {
+		m := New(128, 128, seed)
+		for i := 0; i < 5; i++ {
+			m.PlaceVillage()
+		}
+		if len(m.Villages) == 0 {
+			t.Errorf("seed %d: no villages placed", seed)
+		}
+		for _, v := range m.Villages {
+			if c := m.Cells[m.GetIndex(v.X, v.Y)]; c != TileIDVillage {
+				t.Errorf("seed %d: village at %d,%d has tile %d", seed, v.X, v.Y, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package genmap2derosion
+
+import (
+	"image/color"
+
+	"github.com/Flokey82/go_gens/genbiome"
+)
+
+// ClimateParams contains the parameters for converting the simulated climate
+// to real world units.
+type ClimateParams struct {
+	SeaLevel           float64 // sea level in the climate heightmap (in meters)
+	TemperatureMin     float64 // temperature at a simulated temperature of 0 (in °C)
+	TemperatureMax     float64 // temperature at a simulated temperature of 1 (in °C)
+	PrecipitationScale float64 // yearly precipitation at constant rain (in cm)
+}
+
+// DefaultClimateParams are the default climate parameters.
+var DefaultClimateParams = ClimateParams{
+	SeaLevel:           200,
+	TemperatureMin:     -10,
+	TemperatureMax:     40,
+	PrecipitationScale: 1500,
+}
+
+// genBiome assigns the Whittaker biome to each cell based on the average
+// temperature and precipitation.
+func (c *Climate) genBiome() {
+	for i := range c.heightmap {
+		c.BiomeMap[i] = genbiome.Whittaker(c.Temperature(i), c.Precipitation(i))
+	}
+}
+
+// Temperature returns the average temperature of the given cell (in °C).
+func (c *Climate) Temperature(i int) float64 {
+	p := &c.params
+	return p.TemperatureMin + (p.TemperatureMax-p.TemperatureMin)*c.AvgTempMap[i]
+}
+
+// Precipitation returns the average yearly precipitation of the given
+// cell (in cm).
+func (c *Climate) Precipitation(i int) float64 {
+	return c.AvgRainMap[i] * c.params.PrecipitationScale
+}
+
+// IsWater returns true if the given cell is below sea level.
+func (c *Climate) IsWater(i int) bool {
+	return c.heightmap[i] <= c.params.SeaLevel
+}
+
+// ExportBiomePng exports the biome map as PNG, shaded by elevation, where
+// cells below sea level and water pools are colored as water.
+func (w *World) ExportBiomePng(path string) error {
+	c := w.Climate()
+	return w.writePNG(path, func(i int) color.NRGBA {
+		if c.IsWater(i) || w.waterpool[i] > 0 {
+			return ColorWater
+		}
+		return c.BiomeMap[i].Color()
+	})
+}
@@ -1,49 +1,43 @@
 package genmap2derosion
 
 import (
-	"log"
-	"math/rand"
 	"time"
 
+	"github.com/Flokey82/go_gens/genbiome"
 	opensimplex "github.com/ojrac/opensimplex-go"
 )
 
+// genClimate generates the climate for the current heightmap.
 func (w *World) genClimate() *Climate {
 	dimX := w.params.Size.X
 	dimY := w.params.Size.Y
 
 	// Initialize terrain heightmap.
-	//
-	// Generate new heightmap from actual heightmap, scaled to
-	// the expected height values.
-	heightmap := make([]float64, len(w.heightmap))
-	for i := range heightmap {
-		heightmap[i] = w.heightmap[i]*4000 - 300
-	}
+	heightmap := w.climateHeightmap()
 
 	// Initialize climate.
 	climate := NewClimate(int(dimX), int(dimY), 0, int(w.params.Seed), heightmap)
+	climate.params = w.params.Climate
 
 	// Calculate the climate system.
+	now := time.Now()
 	climate.calcAverage()
+	w.logf("Climate simulation took %v", time.Since(now))
 
 	// Generate the surface composition.
 	climate.genBiome()
 
-	// Generate climate. This is really suboptimal.
-	now := time.Now()
-
 	if w.params.StorePNGCycles {
 		w.ExportPng("b_image_terrain.png", heightmap)
 	}
 
-	// Run the simulation for 365 days.
-	for day := 0; day < 365; day++ {
-		// Run the simulation.
-		log.Println(day)
-		climate.runSimulation(day)
+	if w.params.StoreGIFFrames {
+		// Run the simulation for 365 days to visualize the weather.
+		// This is really suboptimal.
+		for day := 0; day < 365; day++ {
+			// Run the simulation.
+			climate.runSimulation(day)
 
-		if w.params.StoreGIFFrames {
 			// Build a hacky float map that is supposed to represent
 			// rain and clouds that we can export as a GIF frame.
 			// TODO: Remove or improve.
@@ -67,17 +61,43 @@ func (w *World) genClimate() *Climate {
 	}
 
 	if w.params.StorePNGCycles {
-		w.ExportPng("b_image_avterrain.png", heightmap)
-		w.ExportPng("b_image_avgrain.png", climate.AvgRainMap)
-		w.ExportPng("b_image_avgtemp.png", climate.AvgTempMap)
-		w.ExportPng("b_image_avgwind.png", climate.AvgWindMap)
-		w.ExportPng("b_image_avgcloud.png", climate.AvgCloudMap)
+		w.exportClimate(climate, "")
 	}
-
-	log.Println(time.Since(now))
+	w.climate = climate
 	return climate
 }
 
+// UpdateClimate recomputes the climate from the current (eroded) heightmap.
+func (w *World) UpdateClimate() {
+	if w.climate == nil {
+		w.genClimate()
+		return
+	}
+	now := time.Now()
+	w.climate.Update(w.climateHeightmap())
+	w.logf("Climate update took %v", time.Since(now))
+}
+
+// climateHeightmap returns the heightmap scaled to the height values
+// expected by the climate simulation (in meters).
+func (w *World) climateHeightmap() []float64 {
+	heightmap := make([]float64, len(w.heightmap))
+	for i := range heightmap {
+		heightmap[i] = w.heightmap[i]*4000 - 300
+	}
+	return heightmap
+}
+
+// exportClimate exports the average climate maps as PNGs, where the
+// suffix is appended to the file names.
+func (w *World) exportClimate(c *Climate, suffix string) {
+	w.ExportPng("b_image_avterrain"+suffix+".png", c.heightmap)
+	w.ExportPng("b_image_avgrain"+suffix+".png", c.AvgRainMap)
+	w.ExportPng("b_image_avgtemp"+suffix+".png", c.AvgTempMap)
+	w.ExportPng("b_image_avgwind"+suffix+".png", c.AvgWindMap)
+	w.ExportPng("b_image_avgcloud"+suffix+".png", c.AvgCloudMap)
+}
+
 type Climate struct {
 	perlin     opensimplex.Noise // Open simplex which we pretend to be perlin
 	seed       int               // Seed for Perlin Noise
@@ -99,10 +119,12 @@ type Climate struct {
 	AvgHumidityMap []float64 // average humidity over time
 
 	// Biome mapping.
-	biomeMap []int
+	BiomeMap []genbiome.Biome // biome of each cell (see genBiome)
 
 	// Heightmap.
 	heightmap []float64
+
+	params ClimateParams // conversion to real world units
 }
 
 func NewClimate(dimX, dimY, day, seed int, heightmap []float64) *Climate {
@@ -121,13 +143,28 @@ func NewClimate(dimX, dimY, day, seed int, heightmap []float64) *Climate {
 		AvgCloudMap:    make([]float64, idxSize),
 		AvgTempMap:     make([]float64, idxSize),
 		AvgHumidityMap: make([]float64, idxSize),
-		biomeMap:       make([]int, idxSize),
+		BiomeMap:       make([]genbiome.Biome, idxSize),
 		heightmap:      heightmap,
+		params:         DefaultClimateParams,
 	}
 	c.init(day)
 	return c
 }
 
+// Update recomputes the average climate and the biomes for the given
+// heightmap (e.g. after erosion changed the terrain).
+func (c *Climate) Update(heightmap []float64) {
+	c.heightmap = heightmap
+	c.init(0)
+	c.calcAverage()
+	c.genBiome()
+}
+
+// Biomes returns the biome of each cell (see genBiome).
+func (c *Climate) Biomes() []genbiome.Biome {
+	return c.BiomeMap
+}
+
 func (c *Climate) init(day int) {
 	if c.perlin == nil {
 		c.perlin = opensimplex.New(int64(c.seed))
@@ -457,54 +494,6 @@ func (c *Climate) calcRainMap() {
 	}
 }
 
-func (c *Climate) genBiome() {
-	// Determine the Surface Biome:
-	// 0: Water
-	// 1: Sandy Beach
-	// 2: Gravel Beach
-	// 3: Stone Beach Cliffs
-	// 4: Wet Plains (Grassland)
-	// 5: Dry Plains (Shrubland)
-	// 6: Rocky Hills
-	// 7: Tempererate Forest
-	// 8: Boreal Forest
-	// 9: Mountain Tundra
-	// 10: Mountain Peak
-	// Compare the Parameters and decide what kind of ground we have.
-	for i := range c.heightmap {
-		switch d := c.heightmap[i]; {
-		case d <= 200:
-			c.biomeMap[i] = 0 // 0: Water
-		case d <= 204:
-			c.biomeMap[i] = 1 // 1: Sandy Beach
-		case d <= 210:
-			c.biomeMap[i] = 2 // 2: Gravel Beach
-		case d <= 220:
-			c.biomeMap[i] = 3 // 3: Stony Beach Cliffs
-		case d <= 600:
-			if c.AvgRainMap[i] >= 0.02 {
-				c.biomeMap[i] = 4 // 4: Wet Plains (Grassland)
-			} else {
-				c.biomeMap[i] = 5 // 5: Dry Plains (Shrubland)
-			}
-		case d <= 1300:
-			x := i / c.dimY
-			y := i % c.dimY
-			if c.AvgRainMap[i] < 0.001 && x+rand.Int()%4-2 > 5 && x+rand.Int()%4-2 < 95 && y+rand.Int()%4-2 > 5 && y+rand.Int()%4-2 < 95 {
-				c.biomeMap[i] = 6 //6: Rocky Hills
-			} else if d <= 1100 {
-				c.biomeMap[i] = 7 //7: Temperate Forest
-			} else {
-				c.biomeMap[i] = 8 //8: Boreal Forest
-			}
-		case d <= 1500:
-			c.biomeMap[i] = 9
-		default:
-			c.biomeMap[i] = 10 //Otherwise just Temperate Forest
-		}
-	}
-}
-
 /*
 type Vegetation struct {
 }
@@ -17,7 +17,11 @@ func main() {
 	pprof.StartCPUProfile(f)
 	defer pprof.StopCPUProfile()
 
-	w := genmap2derosion.NewWorld(genmap2derosion.DefaultParams)
+	params := *genmap2derosion.DefaultParams
+	params.StoreGIFFrames = true
+	params.Logger = log.Default()
+	w := genmap2derosion.NewWorld(&params)
+	w.Generate()
 	if err := w.ExportGif("anim.gif"); err != nil {
 		log.Fatal(err)
 	}
@@ -1,21 +1,45 @@
 package genmap2derosion
 
 import (
-	"fmt"
-	"log"
 	"math"
+	"sort"
 	"time"
 
 	"github.com/Flokey82/go_gens/vectors"
 )
 
-var (
-	lrate    = float64(0.01)
-	lrateInv = (1.0 - lrate)
-)
-
 // Hydraulic erosion
 func (w *World) doErosion(cycles, drops int) {
+	sx := int(w.params.Size.X)
+	sy := int(w.params.Size.Y)
+	w.doErosionWithSpawn(cycles, drops, func() int {
+		// Spawn new particle at a random position.
+		return w.r.Intn(sx)*sy + w.r.Intn(sy)
+	})
+}
+
+// doErosionRain performs a number of erosion cycles, where the drops spawn
+// proportionally to the given precipitation map.
+func (w *World) doErosionRain(cycles, drops int, rmap []float64) {
+	// Build the cumulative distribution of the precipitation, so we can
+	// pick random locations weighted by the precipitation.
+	cdf := make([]float64, len(rmap))
+	var sum float64
+	for i, r := range rmap {
+		sum += r
+		cdf[i] = sum
+	}
+	if sum <= 0 {
+		return // No rain, no erosion.
+	}
+	w.doErosionWithSpawn(cycles, drops, func() int {
+		return sort.SearchFloat64s(cdf, w.r.Float64()*sum)
+	})
+}
+
+// doErosionWithSpawn performs a number of erosion cycles, where each drop
+// spawns at the index returned by the spawn function.
+func (w *World) doErosionWithSpawn(cycles, drops int, spawn func() int) {
 	// Reset drains.
 	for i := range w.waterdrains {
 		w.waterdrains[i] = -1
@@ -24,14 +48,14 @@ func (w *World) doErosion(cycles, drops int) {
 	// Do a series of iterations!
 	now := time.Now()
 	for i := 0; i < cycles; i++ {
-		fmt.Println(fmt.Sprintf("Erode... (Cycle %d/%d)", i, cycles))
-		w.erode(drops)
+		w.logf("Erode... (Cycle %d/%d)", i, cycles)
+		w.erode(drops, spawn)
 	}
-	log.Println(time.Since(now))
+	w.logf("Erosion took %v", time.Since(now))
 }
 
 // erode performs one iteration of erosion with the given number of drops.
-func (w *World) erode(drops int) {
+func (w *World) erode(drops int, spawn func() int) {
 	sx := int(w.params.Size.X)
 	sy := int(w.params.Size.Y)
 
@@ -39,16 +63,17 @@ func (w *World) erode(drops int) {
 	// and river paths.
 	track := make([]int, sx*sy)
 	for j := 0; j < drops; j++ {
-		// Spawn new particle at a random position.
+		// Spawn new particle at the position chosen by the spawn function.
+		idx := spawn()
 		drop := NewDrop(vectors.NewVec2(
-			float64(w.r.Intn(sx)),
-			float64(w.r.Intn(sy)),
+			float64(idx/sy),
+			float64(idx%sy),
 		))
 
 		// Spill limits the number of times we can perform a flood and/or
 		// attempt to move the drop downhll.
 		spill := 5
-		for drop.volume > minVol && spill != 0 {
+		for drop.volume > w.params.Erosion.MinVolume && spill != 0 {
 			// Move the drop downhill and keep track of the path
 			// that it takes.
 			drop.descend(w, track)
@@ -56,7 +81,7 @@ func (w *World) erode(drops int) {
 			// If we still have a sizable water volume left after
 			// moving the drop, perform a flood(fill) at the current
 			// position where the drop came to rest.
-			if drop.volume > minVol {
+			if drop.volume > w.params.Erosion.MinVolume {
 				drop.flood(w)
 			}
 			spill--
@@ -65,6 +90,8 @@ func (w *World) erode(drops int) {
 
 	// Update the waterpath by checking if we recorded drops
 	// passing through any given location.
+	lrate := w.params.Erosion.PathLearningRate
+	lrateInv := 1.0 - lrate
 	for i, t := range track {
 		if t > 0 {
 			// We had some drops come through, so we refesh the value
@@ -87,93 +114,6 @@ func (w *World) erode(drops int) {
 	}
 }
 
-// erodeRain is an experimental variation of the "erode" function which initializes
-// drops based on the precipitation values we have calculated for each location.
-//
-// NOTE: This is untested and probably not working very well due to the climate
-// simulation and the probably low-quality precipitation data it produces.
-func (w *World) erodeRain(cycles int, rmap []float64) {
-	// Reset all recorded drains.
-	for i := range w.waterdrains {
-		w.waterdrains[i] = -1
-	}
-
-	lrate := float64(0.01)
-	lrateInv := (1.0 - lrate)
-
-	now := time.Now()
-	sx := int(w.params.Size.X)
-	sy := int(w.params.Size.Y)
-
-	// Perform a number of erosion cycles.
-	for i := 0; i < cycles; i++ {
-		fmt.Println(fmt.Sprintf("Erode... (Cycle %d/%d)", i, cycles))
-
-		// Track the Movement of all Particles
-		track := make([]int, sx*sy)
-
-		// Instead of spawning a given number of drops at random locations,
-		// we spawn drops at all locations where rain falls.
-		for j := 0; j < len(rmap); j++ {
-			// If the precipitation value is below the minimum,
-			// skip this location.
-			if rmap[j] <= minVol {
-				continue
-			}
-			// Spawn a new particle at the given location.
-			drop := NewDrop(vectors.NewVec2(
-				float64(j/sy),
-				float64(j%sy),
-			))
-
-			// Set the drop volume to the precipitation value.
-			drop.volume = rmap[j]
-
-			// Spill limits the number of times we can perform a flood and/or
-			// attempt to move the drop downhll.
-			spill := 5
-
-			// As long as we have still enough water to move the drop,
-			// keep moving it.
-			for drop.volume > minVol && spill != 0 {
-				// Move the drop downhill and keep track of the path
-				// that it takes.
-				drop.descend(w, track)
-
-				// If we still have a sizable water volume left after
-				// moving the drop, perform a flood(fill) at the current
-				// position where the drop came to rest.
-				if drop.volume > minVol {
-					drop.flood(w)
-				}
-				spill--
-			}
-		}
-
-		// Update the waterpath by checking if we recorded drops
-		// passing through any given location.
-		for i, t := range track {
-			if t > 0 {
-				// We had some drops come through, so we refesh the value
-				// at the given position by adding the lrate constant.
-				//
-				// NOTE: This doesn't seem quite right? Shouldn't a higher
-				// drop count mean that we increase the path value proportionally
-				// to the throughflow?
-				w.waterpath[i] = lrateInv*w.waterpath[i] + lrate
-			} else {
-				// Fade a path value if we didn't have any drops passing through
-				// during this pass.
-				w.waterpath[i] = 0.25 * lrateInv * w.waterpath[i]
-			}
-		}
-
-		// If we should store GIF frames, do so.
-		w.storeGifFrame(w.heightmap, w.waterpath, w.waterpool)
-	}
-	log.Println(time.Since(now))
-}
-
 type Drop struct {
 	index    int64        // Current position (expressed as index into the heightmap)
 	pos      vectors.Vec2 // Current position (expressed as x,y)
@@ -198,24 +138,14 @@ func NewDropWithVolume(pos vectors.Vec2, v float64) Drop {
 	}
 }
 
-// Constants for hydrology and erosion.
-// TODO: Move this somewhere else.
-const (
-	dt             = 1.2   // Delta T / time factor
-	density        = 1.0   // This gives varying amounts of inertia and stuff...
-	evapRate       = 0.001 // The rate of evaporation
-	depositionRate = 0.1   // The rate of sediment deposition
-	minVol         = 0.01  // Minimum water volume
-	friction       = 0.1   // Friction coefficient influencing the speed of the drop
-	volumeFactor   = 100.0 // Factor of water volume to height. A volume of 100 equals a height of 1.
-)
-
 func (d *Drop) descend(w *World, track []int) {
 	dim := w.params.Size
+	ep := &w.params.Erosion
+	dt := ep.TimeStep
 	var acc vectors.Vec2
 	var ind, nind int64
 	var effR, effD, effF, dropMass float64
-	for d.volume > minVol {
+	for d.volume > ep.MinVolume {
 		// Initial Position
 		ind = int64(d.pos.X)*dim.Y + int64(d.pos.Y)
 
@@ -225,21 +155,20 @@ func (d *Drop) descend(w *World, track []int) {
 		n := w.surfaceNormal(ind)
 
 		// Effective Parameter Set
-		// Higher toughness means less erosion.
-		// effT := d.depositionRate * math.Max(0.0, 1.0-w.sedimentToughness[ind])
+		// NOTE: Harder rock means less erosion (see removeMaterial).
 
 		// Higher plant density means less erosion.
-		effD = depositionRate // * math.Max(0.0, 1.0-w.plantdensity[ind])
+		effD = ep.DepositionRate * math.Max(0.0, 1.0-w.plantdensity[ind]*w.params.Vegetation.ErosionReduction)
 
 		// Lower Friction, Lower Evaporation in streams
 		// makes particles prefer established streams -> "curvy".
-		effF = friction * (1.0 - 0.5*w.waterpath[ind])
-		effR = evapRate * (1.0 - 0.2*w.waterpath[ind])
+		effF = ep.Friction * (1.0 - 0.5*w.waterpath[ind])
+		effR = ep.EvaporationRate * (1.0 - 0.2*w.waterpath[ind])
 
 		// Newtonian Mechanics
 
 		// Calculate the mass of the drop.
-		dropMass = d.volume * density
+		dropMass = d.volume * ep.Density
 
 		// Calculate the acceleration vector based on the normal vector and drop mass.
 		acc = vectors.NewVec2(n.X/dropMass, n.Z/dropMass)
@@ -286,17 +215,23 @@ func (d *Drop) descend(w *World, track []int) {
 		// Calculate how much sediment is either eroded or deposited.
 		sedimentDiff := dt * eqCDiff * effD
 
-		// Increase or decrease the sediment in the drop.
-		d.sediment += sedimentDiff // * effT
+		// Remove the calculated sediment amount from the heightmap or
+		// deposit it as loose sediment. Hard rock erodes slower, so less
+		// sediment might be taken up by the drop.
+		if sedimentDiff > 0 {
+			sedimentDiff = w.removeMaterial(ind, d.volume*sedimentDiff) / d.volume
+		} else {
+			w.depositMaterial(ind, -d.volume*sedimentDiff)
+		}
 
-		// Remove the calculated sediment amount from the heightmap.
-		w.heightmap[ind] -= d.volume * sedimentDiff // * effT
+		// Increase or decrease the sediment in the drop.
+		d.sediment += sedimentDiff
 
 		// Remove the calculated sediment amount from the sedimentmap.
 		// This we use to keep track of where erosion has happened and
 		// where sediment has been deposited to identify potential
 		// fertile land etc.
-		w.sediment[ind] -= d.volume * sedimentDiff // * effT
+		w.sediment[ind] -= d.volume * sedimentDiff
 
 		// Evaporate (Mass Conservative)
 		//
@@ -326,6 +261,8 @@ func (d *Drop) flood(w *World) {
 	fail := 10
 
 	drainage := w.drainage
+	volumeFactor := w.params.Erosion.VolumeFactor
+	minVol := w.params.Erosion.MinVolume
 	size := dim.X * dim.Y
 
 	// Tried keeps track of all cells / indices / locations we
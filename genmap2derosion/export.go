@@ -116,6 +116,34 @@ func (w *World) ExportPng(name string, h []float64) {
 	}
 }
 
+// writePNG exports a PNG using the given function to look up the color of
+// each cell, which is shaded by elevation.
+func (w *World) writePNG(path string, colorFn func(i int) color.NRGBA) error {
+	width, height := int(w.params.Size.X), int(w.params.Size.Y)
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	min, max := w.getMinMax()
+	for i := range w.heightmap {
+		x, y := w.coordinates(i)
+		col := colorFn(i)
+		shade := 0.6 + 0.4*(w.heightmap[i]-min)/(max-min)
+		img.Set(x, y, color.NRGBA{
+			R: uint8(float64(col.R) * shade),
+			G: uint8(float64(col.G) * shade),
+			B: uint8(float64(col.B) * shade),
+			A: 255,
+		})
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func (w *World) exportCombined(name string, heightMap, waterPath, waterPool []float64) {
 	width, height := int(w.params.Size.X), int(w.params.Size.Y)
 
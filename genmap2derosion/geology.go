@@ -0,0 +1,158 @@
+package genmap2derosion
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/Flokey82/go_gens/genheightmap"
+	opensimplex "github.com/ojrac/opensimplex-go"
+)
+
+// Stratum is a layer of rock.
+type Stratum struct {
+	Thickness float64 // thickness of the layer (in height units)
+	Hardness  float64 // hardness of the rock (0.0 soft - 1.0 hard), which reduces the erosion rate
+}
+
+// DefaultStrata are the default rock layers, alternating between soft and
+// hard rock, which results in cliffs and terraces.
+var DefaultStrata = []Stratum{
+	{Thickness: 0.06, Hardness: 0.2},
+	{Thickness: 0.02, Hardness: 0.8},
+	{Thickness: 0.04, Hardness: 0.4},
+	{Thickness: 0.03, Hardness: 0.9},
+	{Thickness: 0.05, Hardness: 0.1},
+}
+
+// initGeology sets up the rock layers. The layers repeat with the elevation and
+// are warped by noise, so they don't form perfectly horizontal bands.
+func (w *World) initGeology() {
+	w.soil = make([]float64, len(w.heightmap))
+	w.strataOffset = make([]float64, len(w.heightmap))
+	noise := opensimplex.New(w.params.Seed)
+	sy := float64(w.params.Size.Y)
+	for i := range w.strataOffset {
+		x, y := w.coordinates(i)
+		w.strataOffset[i] = noise.Eval2(float64(x)/sy*3, float64(y)/sy*3) * w.params.StrataWarp
+	}
+}
+
+// hardness returns the hardness of the exposed bedrock at the given index.
+func (w *World) hardness(i int64) float64 {
+	strata := w.params.Strata
+	var total float64
+	for _, s := range strata {
+		total += s.Thickness
+	}
+	if total <= 0 {
+		return 0
+	}
+	depth := math.Mod(w.heightmap[i]-w.soil[i]+w.strataOffset[i], total)
+	if depth < 0 {
+		depth += total
+	}
+	for _, s := range strata {
+		if depth < s.Thickness {
+			return math.Max(0, math.Min(1, s.Hardness))
+		}
+		depth -= s.Thickness
+	}
+	return strata[len(strata)-1].Hardness
+}
+
+// erodibleAmount returns the amount of material that would be removed if the
+// given amount of material is eroded at the given index. Loose sediment
+// (soil) is removed first, the bedrock below erodes at a rate reduced by its
+// hardness.
+func (w *World) erodibleAmount(i int64, amount float64) float64 {
+	fromSoil := math.Min(amount, w.soil[i])
+	return fromSoil + (amount-fromSoil)*(1-w.hardness(i))
+}
+
+// removeMaterial erodes the given amount of material at the given index and
+// returns the amount that was actually removed (see erodibleAmount).
+func (w *World) removeMaterial(i int64, amount float64) float64 {
+	removed := w.erodibleAmount(i, amount)
+	w.takeMaterial(i, removed)
+	return removed
+}
+
+// takeMaterial removes exactly the given amount of material at the given
+// index, starting with the loose sediment (soil).
+func (w *World) takeMaterial(i int64, amount float64) {
+	w.soil[i] -= math.Min(amount, w.soil[i])
+	w.heightmap[i] -= amount
+}
+
+// depositMaterial deposits the given amount of sediment at the given index.
+func (w *World) depositMaterial(i int64, amount float64) {
+	w.soil[i] += amount
+	w.heightmap[i] += amount
+}
+
+// ThermalErode runs the given number of iterations of thermal erosion, where
+// material collapses from slopes steeper than the talus angle and comes to
+// rest on the lower neighbors as loose sediment.
+func (w *World) ThermalErode(iterations int) {
+	ep := &w.params.Erosion
+	delta := make([]float64, len(w.heightmap))
+	moved := func(i int, excess float64) float64 {
+		return w.erodibleAmount(int64(i), ep.ThermalRate*excess/2)
+	}
+	for it := 0; it < iterations; it++ {
+		genheightmap.ThermalErosionDelta(w.heightmap, delta, w.getNeighbors, ep.ThermalTalus, moved)
+		for i, d := range delta {
+			if d > 0 {
+				w.depositMaterial(int64(i), d)
+			} else if d < 0 {
+				w.takeMaterial(int64(i), -d)
+			}
+		}
+	}
+}
+
+// Soil returns the thickness of the loose sediment on top of the bedrock.
+func (w *World) Soil() []float64 {
+	return w.soil
+}
+
+// Hardness returns the hardness of the exposed bedrock.
+func (w *World) Hardness() []float64 {
+	res := make([]float64, len(w.heightmap))
+	for i := range res {
+		res[i] = w.hardness(int64(i))
+	}
+	return res
+}
+
+// Colors used by ExportGeologyPng.
+var (
+	ColorWater    = color.NRGBA{67, 162, 202, 255}
+	ColorSoil     = color.NRGBA{170, 150, 90, 255}  // alluvial sediment
+	ColorSoftRock = color.NRGBA{190, 120, 90, 255}  // rock with hardness 0
+	ColorHardRock = color.NRGBA{110, 110, 120, 255} // rock with hardness 1
+)
+
+// ExportGeologyPng exports a PNG showing water, deposited sediment and the
+// exposed bedrock colored by its hardness, shaded by elevation.
+func (w *World) ExportGeologyPng(path string) error {
+	return w.writePNG(path, func(i int) color.NRGBA {
+		switch {
+		case w.waterpool[i] > 0.0 || w.waterpath[i] > 0.01:
+			return ColorWater
+		case w.soil[i] > w.params.SoilThreshold:
+			return ColorSoil
+		}
+		return lerpColor(ColorSoftRock, ColorHardRock, w.hardness(int64(i)))
+	})
+}
+
+// lerpColor interpolates linearly between the colors a and b.
+func lerpColor(a, b color.NRGBA, t float64) color.NRGBA {
+	return color.NRGBA{
+		R: uint8(float64(a.R) + (float64(b.R)-float64(a.R))*t),
+		G: uint8(float64(a.G) + (float64(b.G)-float64(a.G))*t),
+		B: uint8(float64(a.B) + (float64(b.B)-float64(a.B))*t),
+		A: 255,
+	}
+}
@@ -31,7 +31,7 @@ func (w *World) addVolCone(slope float64) {
 }
 
 func (w *World) addMountains(n int, r float64) {
-	w.ApplyGen(genheightmap.GenMountains(1, 1, n, r)) // float64(w.dim.X), float64(w.dim.Y)
+	w.ApplyGen(genheightmap.GenMountains(1, 1, n, r, w.params.Seed)) // float64(w.dim.X), float64(w.dim.Y)
 }
 
 func (w *World) addNoise(amount float64) {
@@ -1,131 +1,180 @@
 package genmap2derosion
 
-/*
-func (w *World) growTrees() {
-  // Random Position
-  {
-    i := rand.Int()%(w.dim.x*w.dim.y)
-    n := surfaceNormal(i, w.heightmap, w.dim, w.scale)
-
-    if w.waterpool[i] == 0.0 && w.waterpath[i] < 0.2 && n.y > 0.8 {
-
-      Plant ntree(i, w.dim)
-      ntree.root(w.plantdensity, w.dim, 1.0)
-      w.trees.push_back(ntree)
-    }
-  }
-
-  // Loop over all Trees
-  for i := 0; i < w.trees.size(); i++ {
-
-    // Grow the Tree
-    w.trees[i].grow()
-
-    // Spawn a new Tree!
-    if rand()%50 == 0 {
-      // Find New Position
-      glm::vec2 npos = w.trees[i].pos + glm::vec2(rand()%9-4, rand()%9-4)
-
-      // Check for Out-Of-Bounds
-      if npos.x >= 0 && npos.x < dim.x && npos.y >= 0 && npos.y < dim.y {
-
-        Plant ntree(npos, w.dim)
-        n := surfaceNormal(ntree.index, w.heightmap, w.dim, w.scale)
-
-        if w.waterpool[ntree.index] == 0.0 && w.waterpath[ntree.index] < 0.2 && n.y > 0.8 && (double)(rand()%1000)/1000.0 > w.plantdensity[ntree.index] {
-          ntree.root(w.plantdensity, w.dim, 1.0)
-          w.trees.push_back(ntree)
-        }
-      }
-    }
-
-    // If the tree is in a pool or in a stream, kill it.
-    if w.waterpool[w.trees[i].index] > 0.0 || w.waterpath[w.trees[i].index] > 0.2 || rand()%1000 == 0 {
-      //Random Death Chance
-      w.trees[i].root(w.plantdensity, w.dim, -1.0)
-      w.trees.erase(w.trees.begin()+i)
-      i--
-    }
-  }
+import (
+	"image/color"
+	"math"
+
+	"github.com/Flokey82/go_gens/genflora"
+)
+
+// VegetationParams contains the parameters for the vegetation simulation.
+type VegetationParams struct {
+	Iterations       int     // number of vegetation iterations run by Generate after each pass
+	SpawnAttempts    int     // number of attempts to spawn a plant at a random position per iteration
+	GrowthRate       float64 // rate at which the plants approach their maximum size
+	SeedChance       float64 // chance of a plant to spread a seed per iteration
+	SeedRadius       int     // maximum distance of a seed from its parent plant
+	DeathChance      float64 // chance of a plant to die per iteration
+	MaxWaterPath     float64 // maximum water path value (river) a plant can survive in
+	MinNormalY       float64 // minimum Y component of the surface normal (maximum steepness)
+	ErosionReduction float64 // reduction of the erosion rate per unit of plant density
+	SoilFormation    float64 // rate at which roots weather bedrock into soil (per plant size and iteration)
 }
 
+// DefaultVegetationParams are the default vegetation parameters.
+var DefaultVegetationParams = VegetationParams{
+	Iterations:       50,
+	SpawnAttempts:    10,
+	GrowthRate:       0.05,
+	SeedChance:       0.02,
+	SeedRadius:       4,
+	DeathChance:      0.001,
+	MaxWaterPath:     0.2,
+	MinNormalY:       0.8,
+	ErosionReduction: 1.0,
+	SoilFormation:    0.00005,
+}
+
+// Plant is a single plant in the vegetation simulation.
 type Plant struct {
-  pos vec2
-  index int
-  size float32
-  maxsize float32
-  rate float32
-
-  Plant& operator=(const Plant& o){
-    if this != &o {  //Self Check
-      pos = o.pos
-      index = o.index
-      size = o.size
-    }
-    return *this
-  }
+	Index   int     // index of the cell the plant grows in
+	Size    float64 // current size of the plant
+	MaxSize float64 // maximum size of the plant
+	Rate    float64 // growth rate of the plant
 }
 
-func (p *Plant) init() {
-  p.size = 0.5
-  p.maxsize = 1.0
-  p.rate = 0.05
+// grow grows the plant towards its maximum size.
+func (p *Plant) grow() {
+	p.Size += p.Rate * (p.MaxSize - p.Size)
 }
 
-func newPlant(i int, d ivec2) Plant{
-  var p Plant
-  p.index = i
-  p.pos = vec2(i/d.y, i%d.y)
-  p.init()
-  return p
+// GrowVegetation runs the given number of iterations of the vegetation
+// simulation. Plants spawn depending on the biome, grow, spread seeds
+// and die if they are flooded or by chance (see VegetationParams.DeathChance).
+// The plant density reduces the hydraulic erosion and the roots slowly turn
+// bedrock into soil.
+// NOTE: The plants use the current climate (see UpdateClimate).
+func (w *World) GrowVegetation(iterations int) {
+	vp := &w.params.Vegetation
+	c := w.Climate()
+	dimX, dimY := int(w.params.Size.X), int(w.params.Size.Y)
+	for it := 0; it < iterations; it++ {
+		// Spawn plants at random positions.
+		for n := 0; n < vp.SpawnAttempts; n++ {
+			w.spawnPlant(c, w.r.Intn(len(w.heightmap)))
+		}
+
+		// Grow, seed and kill the plants. The seedlings are added to
+		// w.plants by spawnPlant and kept after the surviving plants.
+		plants := w.plants
+		w.plants = nil
+		alive := plants[:0]
+		for _, p := range plants {
+			p.grow()
+
+			// Spread a seed somewhere nearby.
+			if w.r.Float64() < vp.SeedChance {
+				x, y := w.coordinates(p.Index)
+				x += w.r.Intn(2*vp.SeedRadius+1) - vp.SeedRadius
+				y += w.r.Intn(2*vp.SeedRadius+1) - vp.SeedRadius
+				if x >= 0 && x < dimX && y >= 0 && y < dimY {
+					w.spawnPlant(c, x*dimY+y)
+				}
+			}
+
+			// Plants die in pools and streams or by chance.
+			if w.waterpool[p.Index] > 0.0 || w.waterpath[p.Index] > vp.MaxWaterPath || w.r.Float64() < vp.DeathChance {
+				w.root(p.Index, -1.0)
+				continue
+			}
+
+			// Roots weather the bedrock into soil.
+			w.soil[p.Index] += vp.SoilFormation * p.Size
+			alive = append(alive, p)
+		}
+		w.plants = append(alive, w.plants...)
+	}
 }
 
-func newPlantt(p vec2,  d ivec2) Plant{
-  var p Plant
-  p.pos = p
-  p.index = int(p.x)*d.y+int(p.y)
-  p.init()
-  return p
+// spawnPlant tries to spawn a new plant at the given index. The chance
+// depends on the vegetation density of the biome and the local plant density.
+func (w *World) spawnPlant(c *Climate, i int) bool {
+	vp := &w.params.Vegetation
+	if c.IsWater(i) || w.waterpool[i] > 0.0 || w.waterpath[i] >= vp.MaxWaterPath {
+		return false
+	}
+	if w.surfaceNormal(int64(i)).Y < vp.MinNormalY {
+		return false
+	}
+	if w.r.Float64() >= c.BiomeMap[i].VegetationDensity() || w.r.Float64() < w.plantdensity[i] {
+		return false
+	}
+	w.plants = append(w.plants, &Plant{
+		Index:   i,
+		Size:    0.5,
+		MaxSize: 1.0,
+		Rate:    w.params.Vegetation.GrowthRate,
+	})
+	w.root(i, 1.0)
+	return true
 }
 
+// root adds (f > 0) or removes (f < 0) the density of a plant at the given
+// index, which also affects the neighboring cells.
+func (w *World) root(i int, f float64) {
+	x, y := w.coordinates(i)
+	dimX, dimY := int(w.params.Size.X), int(w.params.Size.Y)
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			nx, ny := x+dx, y+dy
+			if nx < 0 || nx >= dimX || ny < 0 || ny >= dimY {
+				continue
+			}
+			weight := 0.4 // diagonal
+			if dx == 0 && dy == 0 {
+				weight = 1.0
+			} else if dx == 0 || dy == 0 {
+				weight = 0.6
+			}
+			w.plantdensity[nx*dimY+ny] += f * weight
+		}
+	}
+}
 
-func (p *Plant) grow() {
-  p.size += p.rate*(p.maxsize-p.size)
+// Plants returns the living plants.
+func (w *World) Plants() []*Plant {
+	return w.plants
 }
 
-func (p *Plant) root(density []float64, dim ivec2, f float64) {
-
-  //Can always do this one
-  density[index] += f*1.0
-
-  if pos.x > 0 {
-    //
-    density[index - 256] += f*0.6      //(-1, 0)
-
-    if pos.y > 0 {
-      density[index - 257] += f*0.4    //(-1, -1)
-    }
-    if pos.y < 256-1 {
-      density[index - 255] += f*0.4    //(-1, 1)
-    }
-  }
-
-  if pos.x < 256-1 {
-    //
-    density[index + 256] += f*0.6    //(1, 0)
-
-    if pos.y > 0 {
-      density[index + 255] += f*0.4    //(1, -1)
-    }
-    if pos.y < 256-1 {
-      density[index + 257] += f*0.4    //(1, 1)
-    }
-  }
-
-  if pos.y > 0 {
-    density[index - 1]   += f*0.6    //(0, -1)
-  }
-  if pos.y < 256-1 {
-    density[index + 1]   += f*0.6    //(0, 1)
-  }
-}*/
+// PlantDensity returns the plant density map.
+func (w *World) PlantDensity() []float64 {
+	return w.plantdensity
+}
+
+// Habitat returns the habitat at the given index, which can be used to select
+// matching plant species (see genflora.SelectLineages).
+func (w *World) Habitat(i int) genflora.Habitat {
+	c := w.Climate()
+	return genflora.Habitat{
+		Temperature:   c.Temperature(i),
+		Precipitation: c.Precipitation(i) * 10, // cm to mm
+		Shade:         math.Min(1, math.Max(0, w.plantdensity[i])),
+		Slope:         1 - w.surfaceNormal(int64(i)).Y,
+	}
+}
+
+// Colors used by ExportVegetationPng.
+var (
+	ColorBarren     = color.NRGBA{200, 190, 150, 255} // no vegetation
+	ColorVegetation = color.NRGBA{30, 110, 40, 255}   // dense vegetation
+)
+
+// ExportVegetationPng exports the plant density as PNG, shaded by elevation.
+func (w *World) ExportVegetationPng(path string) error {
+	return w.writePNG(path, func(i int) color.NRGBA {
+		if w.waterpool[i] > 0.0 || w.waterpath[i] > 0.01 {
+			return ColorWater
+		}
+		return lerpColor(ColorBarren, ColorVegetation, math.Min(1, w.plantdensity[i]))
+	})
+}
@@ -0,0 +1,56 @@
+package genmap2derosion
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+func TestGrowVegetationDensity(t *testing.T) {
+	params := *DefaultParams
+	params.Size = vectors.IVec2{X: 32, Y: 32}
+	params.Vegetation.SpawnAttempts = 50
+	params.Vegetation.SeedChance = 0.2 // spread lots of seedlings
+	w := NewWorld(&params)
+	w.GrowVegetation(100)
+	if len(w.Plants()) == 0 {
+		t.Fatal("no plants were spawned")
+	}
+
+	// The density has to match the roots of the living plants, where each
+	// plant adds 1.0 to its cell, 0.6 to the orthogonal and 0.4 to the
+	// diagonal neighbors (clipped at the edges).
+	dimX, dimY := int(params.Size.X), int(params.Size.Y)
+	want := make([]float64, len(w.heightmap))
+	for _, p := range w.Plants() {
+		x, y := w.coordinates(p.Index)
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				nx, ny := x+dx, y+dy
+				if nx < 0 || nx >= dimX || ny < 0 || ny >= dimY {
+					continue
+				}
+				switch {
+				case dx == 0 && dy == 0:
+					want[nx*dimY+ny] += 1.0
+				case dx == 0 || dy == 0:
+					want[nx*dimY+ny] += 0.6
+				default:
+					want[nx*dimY+ny] += 0.4
+				}
+			}
+		}
+	}
+	var total, wantTotal float64
+	for i, d := range w.PlantDensity() {
+		if math.Abs(d-want[i]) > 1e-9 {
+			t.Errorf("density at %d: got %f, want %f", i, d, want[i])
+		}
+		total += d
+		wantTotal += want[i]
+	}
+	if math.Abs(total-wantTotal) > 1e-6 {
+		t.Errorf("total density: got %f, want %f", total, wantTotal)
+	}
+}
@@ -1,7 +1,6 @@
 package genmap2derosion
 
 import (
-	"log"
 	"sort"
 )
 
@@ -226,9 +225,9 @@ func (w *World) getFlux() []float64 {
 				fluxShed := ((h - w.heightmap[nb]) / sumh) * fluxVol
 				if w.waterdrains[nb] >= 0 {
 					if h < w.heightmap[w.waterdrains[nb]] {
-						log.Println("!!!drain > h")
+						w.logf("!!!drain > h")
 					} else {
-						log.Println("drain < h")
+						w.logf("drain < h")
 					}
 					flux[w.waterdrains[nb]] += fluxShed
 				} else {
@@ -8,28 +8,94 @@ import (
 	"image/color"
 	"math/rand"
 
+	"github.com/Flokey82/go_gens/genheightmap"
 	"github.com/Flokey82/go_gens/vectors"
 )
 
 const worldsize = 256
 
+// Logger is used to report the progress of the simulation.
+// *log.Logger satisfies this interface.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Params contains the parameters of the world generation.
+//
+// NOTE: Zero values are used as they are (e.g. zero Erosion.Passes means that
+// Generate doesn't erode at all), so start from a copy of DefaultParams and
+// adjust the fields you need.
 type Params struct {
-	StoreGIFFrames bool
-	StorePNGCycles bool
+	StoreGIFFrames bool // store a GIF frame after each erosion cycle (see ExportGif)
+	StorePNGCycles bool // export PNGs to the working directory after each pass of Generate
 	Height         int64
 	Width          int64
 	Seed           int64
 	Size           vectors.IVec2
+	Erosion        ErosionParams    // erosion parameters (see DefaultErosionParams)
+	Strata         []Stratum        // rock layers, repeating with the elevation (nil for uniform soft rock)
+	StrataWarp     float64          // maximum vertical displacement of the rock layers through noise
+	SoilThreshold  float64          // minimum sediment thickness to count as soil in exports
+	Vegetation     VegetationParams // vegetation parameters (see DefaultVegetationParams)
+	Climate        ClimateParams    // climate parameters (see DefaultClimateParams)
+	Logger         Logger           // logger for progress messages (nil for silent operation)
 }
 
 var DefaultParams = &Params{
-	StoreGIFFrames: true,
-	StorePNGCycles: true,
-	Seed:           DefaultSeed,
+	Seed: DefaultSeed,
 	Size: vectors.IVec2{
 		X: worldsize,
 		Y: worldsize,
 	},
+	Erosion:       DefaultErosionParams,
+	Strata:        DefaultStrata,
+	StrataWarp:    0.03,
+	SoilThreshold: 0.01,
+	Vegetation:    DefaultVegetationParams,
+	Climate:       DefaultClimateParams,
+}
+
+// ErosionParams contains the parameters for the hydraulic erosion.
+type ErosionParams struct {
+	Passes           int     // number of erosion passes run by Generate
+	StaticClimate    bool    // skip recomputing the climate between the passes of Generate (faster)
+	Cycles           int     // number of erosion cycles per pass
+	Drops            int     // number of drops spawned per cycle
+	TimeStep         float64 // delta T / time factor of the drop simulation
+	Density          float64 // density of the water, which determines the inertia of the drops
+	Friction         float64 // friction coefficient slowing down the drops
+	EvaporationRate  float64 // rate at which the drops evaporate
+	DepositionRate   float64 // rate at which sediment is eroded or deposited
+	MinVolume        float64 // minimum water volume of a drop before it is discarded
+	VolumeFactor     float64 // factor of water volume to height (a volume of 100 equals a height of 1)
+	Drainage         float64 // drainage factor from pools
+	HeightScale      float64 // "physical" height scaling of the map
+	PathLearningRate float64 // rate at which the water paths (rivers) adapt to the drops
+
+	ThermalIterations int     // number of thermal erosion iterations run by Generate after each pass
+	ThermalTalus      float64 // maximum stable height difference between neighbors (talus angle)
+	ThermalRate       float64 // fraction (0.0-1.0) of the excess material moved per iteration
+}
+
+// DefaultErosionParams are the default erosion parameters.
+var DefaultErosionParams = ErosionParams{
+	Passes:           5,
+	Cycles:           50,
+	Drops:            300,
+	TimeStep:         1.2,
+	Density:          1.0,
+	Friction:         0.1,
+	EvaporationRate:  0.001,
+	DepositionRate:   0.1,
+	MinVolume:        0.01,
+	VolumeFactor:     100.0,
+	Drainage:         0.01,
+	HeightScale:      40.0,
+	PathLearningRate: 0.01,
+
+	ThermalIterations: 10,
+	ThermalTalus:      0.02,
+	ThermalRate:       0.5,
 }
 
 type World struct {
@@ -50,17 +116,50 @@ type World struct {
 	// Flux related information (experimental)
 	fluxwaterpool []float64 // (TEMP Flux) Water Pool Storage (Lakes / Ponds)
 
-	// Trees
-	// trees []Plant
-	// plantdensity [worldsize * worldsize]float64 //Density for Plants
+	// Vegetation
+	plants       []*Plant  // Living plants
+	plantdensity []float64 // Density of the plants (reduces erosion)
 
-	// Sediment erosion resistance
-	// sedimentToughness [worldsize * worldsize]float64 // Sediment toughness storage
+	// Climate (generated by Generate, ErodeRain or Climate)
+	climate *Climate
+
+	// Geology
+	soil         []float64 // Thickness of the deposited sediment on top of the bedrock
+	strataOffset []float64 // Vertical displacement of the rock layers
 }
 
 const DefaultSeed = 12356
 
+// NewWorld returns a new world with a generated heightmap.
+// Call Erode to run the erosion or Generate to run the full pipeline.
 func NewWorld(params *Params) *World {
+	w := newWorld(params)
+
+	// Generate basic heightmap.
+	w.genTerrain()
+	return w
+}
+
+// NewWorldFromHeightmap returns a new world using the supplied heightmap,
+// which is normalized to the range [0, 1]. The size of the world is set to
+// the dimensions of the heightmap.
+func NewWorldFromHeightmap(params *Params, h *genheightmap.Heightmap) *World {
+	if params == nil {
+		params = DefaultParams
+	}
+	p := *params
+	p.Size = vectors.IVec2{X: int64(h.Width), Y: int64(h.Height)}
+	w := newWorld(&p)
+	for i := range w.heightmap {
+		x, y := w.coordinates(i)
+		w.heightmap[i] = h.At(x, y)
+	}
+	w.heightNormalize()
+	return w
+}
+
+// newWorld returns a new, flat world.
+func newWorld(params *Params) *World {
 	if params == nil {
 		params = DefaultParams
 	}
@@ -69,14 +168,15 @@ func NewWorld(params *Params) *World {
 	w := &World{
 		params:        params,
 		r:             rand.New(rand.NewSource(params.Seed)),
-		drainage:      0.01,
-		scale:         40.0,
+		drainage:      params.Erosion.Drainage,
+		scale:         params.Erosion.HeightScale,
 		heightmap:     make([]float64, idxSize),
 		sediment:      make([]float64, idxSize),
 		waterpath:     make([]float64, idxSize),
 		waterpool:     make([]float64, idxSize),
 		waterdrains:   make([]int, idxSize),
 		fluxwaterpool: make([]float64, idxSize),
+		plantdensity:  make([]float64, idxSize),
 	}
 
 	// Prepare grayscale palette for GIF (0-255).
@@ -89,20 +189,32 @@ func NewWorld(params *Params) *World {
 		w.waterdrains[i] = -1
 	}
 
-	// Generate basic heightmap.
-	w.genTerrain()
+	// Set up the rock layers.
+	w.initGeology()
+	return w
+}
 
+// Generate runs the full pipeline of climate generation and the configured
+// number of erosion passes. In each pass, the climate is recomputed from the
+// eroded heightmap and the drops spawn proportionally to the rainfall, which
+// is followed by thermal erosion and the growth of vegetation. Finally, the
+// climate and biomes are updated to match the final terrain.
+//
+// NOTE: Each climate update simulates a full year, so the climate is computed
+// Passes+1 times in total. Set Erosion.StaticClimate to only compute it at the
+// start and the end.
+func (w *World) Generate() {
 	// Generate climate.
 	w.genClimate()
 
-	fmt.Println("generated")
-
 	// Erode a few times.
-	for j := 0; j < 5; j++ {
-		w.doErosion(50, 300)
-		// c := w.genClimate()
-		// TODO: Update heightmap in climate struct.
-		// w.erodeRain(1, c.AvgRainMap)
+	for j := 0; j < w.params.Erosion.Passes; j++ {
+		if j > 0 && !w.params.Erosion.StaticClimate {
+			w.UpdateClimate()
+		}
+		w.erodeRain(w.params.Erosion.Cycles)
+		w.ThermalErode(w.params.Erosion.ThermalIterations)
+		w.GrowVegetation(w.params.Vegetation.Iterations)
 
 		// Export hydrology data generated by the original algorithm.
 		if w.params.StorePNGCycles {
@@ -110,6 +222,12 @@ func NewWorld(params *Params) *World {
 			w.ExportPng(fmt.Sprintf("b_image%d_wp.png", j), w.waterpath)
 			w.ExportPng(fmt.Sprintf("b_image%d_wpo.png", j), w.waterpool)
 			w.ExportPng(fmt.Sprintf("b_image%d_sed.png", j), w.sediment)
+			if err := w.ExportGeologyPng(fmt.Sprintf("b_image%d_geo.png", j)); err != nil {
+				w.logf("Failed to export geology: %v", err)
+			}
+			if err := w.ExportVegetationPng(fmt.Sprintf("b_image%d_veg.png", j)); err != nil {
+				w.logf("Failed to export vegetation: %v", err)
+			}
 			w.exportCombined(fmt.Sprintf("b_image%d_combo.png", j), w.heightmap, w.waterpath, w.waterpool)
 		}
 
@@ -127,5 +245,92 @@ func NewWorld(params *Params) *World {
 		// w.export(fmt.Sprintf("b_image%d_flux.png", j), w.getFlux())
 		// w.export(fmt.Sprintf("b_image%d_flux_wpo.png", j), w.fluxwaterpool[:])
 	}
-	return w
+
+	// Update the climate and biomes to match the final terrain.
+	w.UpdateClimate()
+	if w.params.StorePNGCycles {
+		w.exportClimate(w.climate, "_final")
+		if err := w.ExportBiomePng("b_image_biomes.png"); err != nil {
+			w.logf("Failed to export biomes: %v", err)
+		}
+	}
+}
+
+// Erode runs the given number of erosion cycles, each spawning the
+// configured number of drops at random positions.
+func (w *World) Erode(cycles int) {
+	w.doErosion(cycles, w.params.Erosion.Drops)
+}
+
+// ErodeRain recomputes the climate from the current heightmap and runs the
+// given number of erosion cycles, where the drops spawn proportionally to the
+// average rainfall. This results in rain-shadow-driven valleys and rivers
+// originating in the rainy regions.
+func (w *World) ErodeRain(cycles int) {
+	w.UpdateClimate()
+	w.erodeRain(cycles)
+}
+
+// erodeRain runs the given number of erosion cycles using the current climate.
+func (w *World) erodeRain(cycles int) {
+	w.doErosionRain(cycles, w.params.Erosion.Drops, w.climate.AvgRainMap)
+}
+
+// Climate returns the climate matching the current heightmap. The climate is
+// generated if it hasn't been generated yet.
+// NOTE: The climate is not updated automatically after erosion (see UpdateClimate).
+func (w *World) Climate() *Climate {
+	if w.climate == nil {
+		w.genClimate()
+	}
+	return w.climate
+}
+
+// Size returns the dimensions of the world.
+// NOTE: All maps are indexed by x*Size().Y + y.
+func (w *World) Size() vectors.IVec2 {
+	return w.params.Size
+}
+
+// Heightmap returns the heightmap.
+func (w *World) Heightmap() []float64 {
+	return w.heightmap
+}
+
+// Sediment returns the eroded (negative) or deposited (positive) sediment.
+func (w *World) Sediment() []float64 {
+	return w.sediment
+}
+
+// WaterPath returns the water path map (rivers), where higher values
+// indicate more water flowing through the cell.
+func (w *World) WaterPath() []float64 {
+	return w.waterpath
+}
+
+// WaterPool returns the depth of the water pools (lakes, ponds).
+func (w *World) WaterPool() []float64 {
+	return w.waterpool
+}
+
+// ToHeightmap returns a copy of the heightmap as genheightmap.Heightmap.
+func (w *World) ToHeightmap() *genheightmap.Heightmap {
+	h := genheightmap.NewHeightmap(int(w.params.Size.X), int(w.params.Size.Y))
+	for i, v := range w.heightmap {
+		x, y := w.coordinates(i)
+		h.Set(x, y, v)
+	}
+	return h
+}
+
+// coordinates returns the x and y coordinates of the given index.
+func (w *World) coordinates(i int) (int, int) {
+	return i / int(w.params.Size.Y), i % int(w.params.Size.Y)
+}
+
+// logf reports progress if a logger is set.
+func (w *World) logf(format string, v ...interface{}) {
+	if w.params.Logger != nil {
+		w.params.Logger.Printf(format, v...)
+	}
 }
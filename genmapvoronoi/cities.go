@@ -15,6 +15,7 @@ func cityScore(render *Terrain) *vmesh.Heightmap {
 	// This will favor placing cities along (and at the end of)
 	// large rivers.
 	score := getFlux(h).MapF(math.Sqrt)
+	biomes := render.Biomes() // empty if the climate is disabled
 	for i := 0; i < h.Len(); i++ {
 		// If we are below (or at) sea level, or we are in a pool of water,
 		// assign lowest score and continue.
@@ -26,6 +27,11 @@ func cityScore(render *Terrain) *vmesh.Heightmap {
 		// TODO: Add bonus if near ocean or lake.
 		// TODO: Consider sediment/fertility of land.
 
+		// Prefer habitable biomes.
+		if len(biomes) > 0 {
+			score.Values[i] += 0.05 * biomes[i].Habitability()
+		}
+
 		// Prefer points towards the middle of the map.
 		score.Values[i] += 0.01 / (1e-9 + math.Abs(h.Vertices[i].X) - h.Extent.Width/2)
 		score.Values[i] += 0.01 / (1e-9 + math.Abs(h.Vertices[i].Y) - h.Extent.Height/2)
@@ -0,0 +1,79 @@
+package genmapvoronoi
+
+import (
+	"math"
+
+	"github.com/Flokey82/go_gens/genbiome"
+)
+
+// Parameters for the (very rough) climate approximation used to determine
+// the biomes.
+var (
+	TemperatureNorth = -5.0   // temperature at sea level at the top of the map (in °C)
+	TemperatureSouth = 30.0   // temperature at sea level at the bottom of the map (in °C)
+	ElevationScale   = 3000.0 // elevation (in meters) of a height value of 1.0
+	PrecipitationMin = 10.0   // yearly precipitation far from water (in cm)
+	PrecipitationMax = 300.0  // yearly precipitation next to water (in cm)
+	MoistureFalloff  = 0.1    // distance from water at which the precipitation drops to ~37%
+)
+
+// genClimate approximates the temperature and precipitation of each vertex
+// if enabled via Params.Climate.
+// The temperature depends on the latitude (y coordinate) and the elevation,
+// the precipitation on the distance to the sea, lakes and rivers.
+func (r *Terrain) genClimate() {
+	h := r.h
+
+	// Calculate the distance of each vertex to the closest water source.
+	dist := make([]float64, h.Len())
+	var queue []int
+	for i := range dist {
+		if h.Values[i] <= 0 || r.rivers[i] >= 0 {
+			queue = append(queue, i)
+		} else {
+			dist[i] = math.Inf(1)
+		}
+	}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, v := range h.Neighbours(u) {
+			if d := dist[u] + h.Distance(u, v); d < dist[v] {
+				dist[v] = d
+				queue = append(queue, v)
+			}
+		}
+	}
+
+	r.temperature = make([]float64, h.Len())
+	r.precipitation = make([]float64, h.Len())
+	for i := range dist {
+		lat := h.Vertices[i].Y/h.Extent.Height + 0.5
+		t := TemperatureNorth + (TemperatureSouth-TemperatureNorth)*lat
+		r.temperature[i] = genbiome.AdjustTemperature(t, h.Values[i]*ElevationScale)
+		r.precipitation[i] = PrecipitationMin + (PrecipitationMax-PrecipitationMin)*math.Exp(-dist[i]/MoistureFalloff)
+	}
+}
+
+// Temperature returns the approximate average yearly temperature of each
+// vertex (in °C), or nil if the climate is disabled (see Params.Climate).
+func (r *Terrain) Temperature() []float64 {
+	return r.temperature
+}
+
+// Precipitation returns the approximate average yearly precipitation of each
+// vertex (in cm), or nil if the climate is disabled (see Params.Climate).
+func (r *Terrain) Precipitation() []float64 {
+	return r.precipitation
+}
+
+// Biomes returns the Whittaker biome of each vertex (empty if the climate is
+// disabled, see Params.Climate).
+// NOTE: Vertices below sea level are assigned the biome they would have on land.
+func (r *Terrain) Biomes() []genbiome.Biome {
+	biomes := make([]genbiome.Biome, len(r.temperature))
+	for i := range biomes {
+		biomes[i] = genbiome.Whittaker(r.temperature[i], r.precipitation[i])
+	}
+	return biomes
+}
@@ -21,7 +21,7 @@ func (r *Terrain) genTerrain() {
 		//MeshSlope(r.mesh, vectors.RandomVec2(4)),
 		//MeshVolCone(r.mesh, -1),
 		//MeshCone(r.mesh, runif(-1, -1)),
-		//MeshMountains(r.mesh, 50, 0.09),
+		//MeshMountains(r.mesh, 50, 0.09, 1234),
 		MeshRidges(r.mesh, vectors.RandomVec2(4)),
 		MeshRidges(r.mesh, vectors.RandomVec2(4)),
 		MeshRidges(r.mesh, vectors.RandomVec2(4)),
@@ -57,8 +57,10 @@ func MeshVolCone(m *vmesh.Mesh, slope float64) *vmesh.Heightmap {
 	return m.ApplyGen(genheightmap.GenVolCone(slope))
 }
 
-func MeshMountains(m *vmesh.Mesh, n int, r float64) *vmesh.Heightmap {
-	return m.ApplyGen(genheightmap.GenMountains(m.Extent.Width, m.Extent.Height, n, r))
+// MeshMountains returns a heightmap with n mountains of radius r placed
+// randomly using the given seed.
+func MeshMountains(m *vmesh.Mesh, n int, r float64, seed int64) *vmesh.Heightmap {
+	return m.ApplyGen(genheightmap.GenMountains(m.Extent.Width, m.Extent.Height, n, r, seed))
 }
 
 func MeshNoise(m *vmesh.Mesh, slope float64) *vmesh.Heightmap {
@@ -22,6 +22,7 @@ type Params struct {
 	NumCities      int
 	NumTerritories int
 	RiverThreshold float64
+	Climate        bool // approximate the climate and biomes (cities prefer habitable biomes)
 }
 
 var DefaultParams = &Params{
@@ -46,6 +47,8 @@ type Terrain struct {
 	terr            []int              // vertex to territory id mapping
 	borders         [][]voronoi.Vertex // territory border paths
 	cityBorders     [][]voronoi.Vertex
+	temperature     []float64 // approximate avg. yearly temperature (in °C)
+	precipitation   []float64 // approximate avg. yearly precipitation (in cm)
 }
 
 func NewTerrain(params *Params) *Terrain {
@@ -63,6 +66,9 @@ func (r *Terrain) regenMapFeatures() {
 	r.rivers = getRivers(r.h, r.params.RiverThreshold)
 	r.riverPaths = getRiverPaths(r.h, r.params.RiverThreshold)
 	r.coasts = contour(r.h, 0)
+	if r.params.Climate {
+		r.genClimate()
+	}
 
 	// Place cities.
 	placeCities(r)
@@ -0,0 +1,155 @@
+package genworldvoronoi
+
+import (
+	"math"
+
+	"github.com/Flokey82/go_gens/genbiome"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// Parameters for the climate simulation.
+var (
+	TemperatureEquator = 30.0   // avg. yearly temperature at the equator at sea level (in °C)
+	TemperaturePole    = -25.0  // avg. yearly temperature at the poles at sea level (in °C)
+	MaxElevation       = 6000.0 // elevation at an elevation value of 1.0 (in meters)
+	RainfallRate       = 1.5    // rate at which the moisture rains down (per radian travelled)
+	OrographicRate     = 4.0    // additional rainfall rate per unit of elevation rise along the wind
+	PrecipitationScale = 200.0  // yearly precipitation at a rainfall of 1.0 (in cm)
+	MaxRainfall        = 2.0    // maximum relative rainfall (limits the orographic rainfall)
+)
+
+// assignWind assigns the prevailing wind to each region based on the global
+// wind bands (circulation cells).
+//
+// - 0° - 30°: Trade winds (easterlies) blowing towards the equator.
+// - 30° - 60°: Westerlies blowing towards the poles.
+// - 60° - 90°: Polar easterlies blowing towards the equator.
+func (m *Map) assignWind() {
+	m.r_wind = make([]vectors.Vec3, m.mesh.numRegions)
+	for r := range m.r_wind {
+		lat, lon := m.r_latLon[r][0], m.r_latLon[r][1]
+		var east, north float64
+		switch absLat := math.Abs(lat); {
+		case absLat < 30:
+			east, north = -1, -0.5
+		case absLat < 60:
+			east, north = 1, 0.5
+		default:
+			east, north = -1, -0.5
+		}
+		if lat < 0 {
+			north = -north
+		}
+
+		// Convert the local east and north components to a vector on the
+		// surface of the sphere.
+		latRad, lonRad := degToRad(lat), degToRad(lon)
+		vEast := vectors.Vec3{X: -math.Sin(lonRad), Y: math.Cos(lonRad)}
+		vNorth := vectors.Vec3{
+			X: -math.Sin(latRad) * math.Cos(lonRad),
+			Y: -math.Sin(latRad) * math.Sin(lonRad),
+			Z: math.Cos(latRad),
+		}
+		m.r_wind[r] = vEast.Mul(east).Add(vNorth.Mul(north)).Normalize()
+	}
+}
+
+// assignTemperature assigns the avg. yearly temperature to each region based
+// on the latitude and the elevation.
+func (m *Map) assignTemperature() {
+	m.r_temperature = make([]float64, m.mesh.numRegions)
+	for r := range m.r_temperature {
+		t := TemperaturePole + (TemperatureEquator-TemperaturePole)*math.Cos(degToRad(m.r_latLon[r][0]))
+		m.r_temperature[r] = genbiome.AdjustTemperature(t, m.r_elevation[r]*MaxElevation)
+	}
+}
+
+// assignMoisture transports the moisture evaporating from the oceans with the
+// wind. Over land, the moisture rains down depending on the distance travelled,
+// and more rain falls when the wind is forced upwards by rising terrain
+// (orographic rainfall), resulting in dry continental interiors and rain
+// shadows. The transport is repeated until the moisture converges or the
+// maximum number of iterations is reached.
+func (m *Map) assignMoisture() {
+	mesh := m.mesh
+	m.r_moisture = make([]float64, mesh.numRegions)
+	m.r_rainfall = make([]float64, mesh.numRegions)
+
+	// Warmer oceans evaporate more water.
+	evaporation := make([]float64, mesh.numRegions)
+	for r := range evaporation {
+		if m.r_elevation[r] < 0 {
+			t := (m.r_temperature[r] - TemperaturePole) / (TemperatureEquator - TemperaturePole)
+			evaporation[r] = 0.5 + 0.5*math.Max(0, math.Min(1, t))
+		}
+	}
+
+	moisture := make([]float64, mesh.numRegions)
+	var out_r []int
+	for it := 0; it < m.params.MoistureIterations; it++ {
+		var maxChange float64
+		for r := range moisture {
+			// Collect the moisture carried by the wind from the upwind
+			// neighbors.
+			var incoming, upwindElevation, distance, weight float64
+			out_r = mesh.r_circulate_r(out_r, r)
+			for _, nb := range out_r {
+				dir := m.r_xyz[r].Sub(m.r_xyz[nb])
+				w := m.r_wind[nb].Dot(dir.Normalize())
+				if w <= 0 {
+					continue
+				}
+				incoming += w * m.r_moisture[nb]
+				upwindElevation += w * math.Max(0, m.r_elevation[nb])
+				distance += w * dir.Len()
+				weight += w
+			}
+			if weight > 0 {
+				incoming /= weight
+				upwindElevation /= weight
+				distance /= weight
+			}
+
+			// The oceans replenish the moisture.
+			if m.r_elevation[r] < 0 {
+				incoming = math.Max(incoming, evaporation[r])
+			}
+
+			// Rising terrain forces the air upwards, which results in more rain.
+			rise := math.Max(0, m.r_elevation[r]-upwindElevation)
+			rain := incoming * (1 - math.Exp(-RainfallRate*distance-OrographicRate*rise))
+			moisture[r] = incoming - rain
+			maxChange = math.Max(maxChange, math.Abs(moisture[r]-m.r_moisture[r]))
+
+			// The rainfall is relative to the rainfall at the coast of a warm
+			// ocean, which has a moisture of 1.0. The orographic rainfall
+			// does not depend on the distance travelled, so on short steep
+			// slopes the ratio would be unbounded and is capped instead.
+			if base := 1 - math.Exp(-RainfallRate*distance); base > 0 {
+				m.r_rainfall[r] = math.Min(rain/base, MaxRainfall)
+			} else {
+				m.r_rainfall[r] = 0
+			}
+		}
+		m.r_moisture, moisture = moisture, m.r_moisture
+		if maxChange < 1e-4 {
+			break
+		}
+	}
+}
+
+// Precipitation returns the avg. yearly precipitation of the given region
+// (in cm).
+func (m *Map) Precipitation(r int) float64 {
+	return m.r_rainfall[r] * PrecipitationScale
+}
+
+// Biomes returns the Whittaker biome of each region.
+// NOTE: Regions below sea level are assigned the biome they would have on land.
+func (m *Map) Biomes() []genbiome.Biome {
+	biomes := make([]genbiome.Biome, m.mesh.numRegions)
+	for r := range biomes {
+		biomes[r] = genbiome.Whittaker(m.r_temperature[r], m.Precipitation(r))
+	}
+	return biomes
+}
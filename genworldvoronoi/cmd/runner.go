@@ -0,0 +1,23 @@
+package main
+
+import (
+	"log"
+
+	"github.com/Flokey82/go_gens/genworldvoronoi"
+)
+
+func main() {
+	m, err := genworldvoronoi.NewMap(genworldvoronoi.DefaultParams)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := m.ExportSVG("test.svg"); err != nil {
+		log.Fatal(err)
+	}
+	if err := m.ExportPNG("test.png", 2048, 1024); err != nil {
+		log.Fatal(err)
+	}
+	if err := m.ExportOBJ("tmp.obj"); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,161 @@
+package genworldvoronoi
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+
+	"github.com/Flokey82/go_gens/vectors"
+	svgo "github.com/ajstarks/svgo"
+)
+
+// Colors used by the exporters.
+var (
+	ColorOceanShallow = color.NRGBA{67, 162, 202, 255}
+	ColorOceanDeep    = color.NRGBA{8, 48, 107, 255}
+)
+
+// ObjElevationScale is the displacement of the surface at an elevation of 1.0
+// relative to the radius of the planet.
+var ObjElevationScale = 0.05
+
+// regionColor returns the color of the given region, which is the biome
+// color on land and a shade of blue in the ocean.
+func (m *Map) regionColor(r int, biome func(r int) color.NRGBA) color.NRGBA {
+	e := m.r_elevation[r]
+	if e < 0 {
+		return lerpColor(ColorOceanShallow, ColorOceanDeep, math.Min(1, -e))
+	}
+	return biome(r)
+}
+
+// biomeColors returns a function returning the biome color of a region.
+func (m *Map) biomeColors() func(r int) color.NRGBA {
+	biomes := m.Biomes()
+	return func(r int) color.NRGBA {
+		return biomes[r].Color()
+	}
+}
+
+// ExportOBJ exports the planet as Wavefront OBJ file, where the regions are
+// the vertices and the surface is displaced by the elevation above sea level.
+func (m *Map) ExportOBJ(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for r, p := range m.r_xyz {
+		p = p.Mul(1 + math.Max(0, m.r_elevation[r])*ObjElevationScale)
+		fmt.Fprintf(w, "v %f %f %f\n", p.X, p.Y, p.Z)
+	}
+	for t := 0; t < m.mesh.numTriangles; t++ {
+		rs := m.mesh.t_circulate_r(t)
+		fmt.Fprintf(w, "f %d %d %d\n", rs[0]+1, rs[1]+1, rs[2]+1)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ExportPNG exports the planet as PNG with the given dimensions using the
+// equirectangular projection.
+func (m *Map) ExportPNG(path string, width, height int) error {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	colors := m.biomeColors()
+	var r int
+	var out_r []int
+	for y := 0; y < height; y++ {
+		lat := 90 - (float64(y)+0.5)/float64(height)*180
+		for x := 0; x < width; x++ {
+			lon := (float64(x)+0.5)/float64(width)*360 - 180
+			r, out_r = m.findClosestRegion(latLonToCartesian(lat, lon), r, out_r)
+			img.Set(x, y, m.regionColor(r, colors))
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// findClosestRegion returns the region closest to the given point on the unit
+// sphere by walking the mesh from the given start region. This converges to
+// the closest region since the mesh is a delaunay triangulation.
+func (m *Map) findClosestRegion(p vectors.Vec3, start int, out_r []int) (int, []int) {
+	best := start
+	bestDot := m.r_xyz[best].Dot(p)
+	for {
+		current := best
+		out_r = m.mesh.r_circulate_r(out_r, current)
+		for _, nb := range out_r {
+			if d := m.r_xyz[nb].Dot(p); d > bestDot {
+				best, bestDot = nb, d
+			}
+		}
+		if best == current {
+			return best, out_r
+		}
+	}
+}
+
+// ExportSVG exports the voronoi cells of the planet as SVG using the
+// equirectangular projection.
+// NOTE: Cells crossing the antimeridian are not drawn.
+func (m *Map) ExportSVG(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	const width, height = 2000, 1000
+	svg := svgo.New(f)
+	svg.Start(width, height)
+	svg.Rect(0, 0, width, height, "fill: rgb(8, 48, 107)")
+
+	colors := m.biomeColors()
+	var out_t []int
+	for r := 0; r < m.mesh.numRegions; r++ {
+		out_t = m.mesh.r_circulate_t(out_t, r)
+		xs := make([]int, len(out_t))
+		ys := make([]int, len(out_t))
+		minLon, maxLon := math.Inf(1), math.Inf(-1)
+		for i, t := range out_t {
+			lat, lon := cartesianToLatLon(m.t_xyz[t].Normalize())
+			minLon = math.Min(minLon, lon)
+			maxLon = math.Max(maxLon, lon)
+			xs[i] = int((lon + 180) / 360 * width)
+			ys[i] = int((90 - lat) / 180 * height)
+		}
+		if maxLon-minLon > 180 {
+			continue
+		}
+		c := m.regionColor(r, colors)
+		style := fmt.Sprintf("fill: rgb(%d, %d, %d); stroke: rgb(%d, %d, %d)", c.R, c.G, c.B, c.R, c.G, c.B)
+		svg.Polygon(xs, ys, style)
+	}
+	svg.End()
+	return f.Close()
+}
+
+// lerpColor interpolates linearly between the colors a and b.
+func lerpColor(a, b color.NRGBA, t float64) color.NRGBA {
+	return color.NRGBA{
+		R: uint8(float64(a.R) + (float64(b.R)-float64(a.R))*t),
+		G: uint8(float64(a.G) + (float64(b.G)-float64(a.G))*t),
+		B: uint8(float64(a.B) + (float64(b.B)-float64(a.B))*t),
+		A: 255,
+	}
+}
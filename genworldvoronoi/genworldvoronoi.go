@@ -0,0 +1,149 @@
+// Package genworldvoronoi is a graph based planetary map generator.
+//
+// It is based on https://www.redblobgames.com/x/1843-planet-generation/ and
+// a port of https://github.com/redblobgames/1843-planet-generation to Go.
+package genworldvoronoi
+
+import (
+	"math/rand"
+
+	"github.com/Flokey82/go_gens/vectors"
+	opensimplex "github.com/ojrac/opensimplex-go"
+)
+
+// Params contains the parameters for the planet generation.
+type Params struct {
+	Seed               int64
+	NumPoints          int     // number of regions (points on the sphere)
+	NumPlates          int     // number of tectonic plates
+	Jitter             float64 // randomization of the point positions (0.0 - 1.0)
+	OceanPlateChance   float64 // chance of a plate to be an oceanic plate (0.0 - 1.0)
+	MoistureIterations int     // maximum number of iterations of the moisture transport
+}
+
+// DefaultParams are the default parameters.
+var DefaultParams = &Params{
+	Seed:               1234,
+	NumPoints:          20000,
+	NumPlates:          20,
+	Jitter:             0.75,
+	OceanPlateChance:   0.5,
+	MoistureIterations: 500,
+}
+
+// Map is a generated planet.
+type Map struct {
+	params *Params
+	rand   *rand.Rand
+	noise  opensimplex.Noise
+	mesh   *TriangleMesh
+
+	r_xyz    []vectors.Vec3 // position of each region on the unit sphere
+	r_latLon [][2]float64   // latitude and longitude of each region (in degrees)
+	t_xyz    []vectors.Vec3 // centroid of each triangle (voronoi cell corners)
+
+	// Tectonic plates
+	plate_r     []int          // center region of each plate
+	r_plate     []int          // plate (center region) of each region
+	plate_vec   []vectors.Vec3 // movement of each plate (indexed by center region)
+	plate_ocean map[int]bool   // oceanic plates (by center region)
+	r_elevation []float64      // elevation of each region (-1.0 - 1.0)
+	t_elevation []float64      // elevation of each triangle
+
+	// Climate
+	r_wind        []vectors.Vec3 // wind vector of each region
+	r_temperature []float64      // avg. yearly temperature of each region (in °C)
+	r_moisture    []float64      // moisture carried by the wind (0.0 - 1.0)
+	r_rainfall    []float64      // relative rainfall of each region (0.0 - MaxRainfall)
+}
+
+// NewMap generates a new planet using the given parameters (nil for the
+// default parameters).
+func NewMap(params *Params) (*Map, error) {
+	if params == nil {
+		params = DefaultParams
+	}
+	m := &Map{
+		params: params,
+		rand:   rand.New(rand.NewSource(params.Seed)),
+		noise:  opensimplex.New(params.Seed),
+	}
+
+	// Generate the sphere.
+	mesh, r_xyz, r_latLon, err := makeSphere(m.rand, params.NumPoints, params.Jitter)
+	if err != nil {
+		return nil, err
+	}
+	m.mesh = mesh
+	m.r_xyz = r_xyz
+	m.r_latLon = r_latLon
+	m.t_xyz = generateTriangleCenters(mesh, r_xyz)
+
+	// Generate the terrain.
+	m.generatePlates()
+	m.assignRegionElevation()
+	m.assignTriangleElevation()
+
+	// Generate the climate.
+	m.assignWind()
+	m.assignTemperature()
+	m.assignMoisture()
+	return m, nil
+}
+
+// Mesh returns the triangle mesh.
+func (m *Map) Mesh() *TriangleMesh {
+	return m.mesh
+}
+
+// NumRegions returns the number of regions.
+func (m *Map) NumRegions() int {
+	return m.mesh.numRegions
+}
+
+// Position returns the position of the given region on the unit sphere.
+func (m *Map) Position(r int) vectors.Vec3 {
+	return m.r_xyz[r]
+}
+
+// LatLon returns the latitude and longitude of the given region (in degrees).
+func (m *Map) LatLon(r int) (float64, float64) {
+	return m.r_latLon[r][0], m.r_latLon[r][1]
+}
+
+// Neighbors returns the regions adjacent to the given region.
+func (m *Map) Neighbors(r int) []int {
+	return m.mesh.r_circulate_r(nil, r)
+}
+
+// Plates returns the plate (identified by its center region) of each region.
+func (m *Map) Plates() []int {
+	return m.r_plate
+}
+
+// Elevation returns the elevation of each region (-1.0 - 1.0), where
+// regions with an elevation below 0 are below sea level.
+func (m *Map) Elevation() []float64 {
+	return m.r_elevation
+}
+
+// Wind returns the wind vector of each region.
+func (m *Map) Wind() []vectors.Vec3 {
+	return m.r_wind
+}
+
+// Temperature returns the avg. yearly temperature of each region (in °C).
+func (m *Map) Temperature() []float64 {
+	return m.r_temperature
+}
+
+// Moisture returns the moisture carried by the wind over each region.
+func (m *Map) Moisture() []float64 {
+	return m.r_moisture
+}
+
+// Rainfall returns the rainfall of each region relative to the rainfall at
+// the coast of a warm ocean (0.0 - MaxRainfall).
+func (m *Map) Rainfall() []float64 {
+	return m.r_rainfall
+}
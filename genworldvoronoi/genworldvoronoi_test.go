@@ -0,0 +1,74 @@
+package genworldvoronoi
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMakeSphereClosed(t *testing.T) {
+	const numPoints = 1500
+	mesh, r_xyz, _, err := makeSphere(rand.New(rand.NewSource(1)), numPoints, 0.75)
+	if err != nil {
+		t.Fatalf("makeSphere: %v", err)
+	}
+
+	// The south pole is added to close the hole in the projection.
+	if got := mesh.NumRegions(); got != numPoints+1 || len(r_xyz) != got {
+		t.Fatalf("got %d regions (%d positions), want %d", got, len(r_xyz), numPoints+1)
+	}
+
+	// A closed triangle mesh on a sphere has T = 2V - 4 triangles.
+	if got, want := mesh.NumTriangles(), 2*mesh.NumRegions()-4; got != want {
+		t.Errorf("got %d triangles, want %d", got, want)
+	}
+	for s, o := range mesh.Halfedges {
+		if o == -1 {
+			t.Fatalf("side %d has no opposite side", s)
+		}
+		if mesh.Halfedges[o] != s {
+			t.Fatalf("side %d: opposite side %d points to %d", s, o, mesh.Halfedges[o])
+		}
+		if mesh.s_begin_r(s) != mesh.s_end_r(o) || mesh.s_end_r(s) != mesh.s_begin_r(o) {
+			t.Fatalf("side %d and its opposite side %d don't share the same regions", s, o)
+		}
+	}
+}
+
+func TestNewMap(t *testing.T) {
+	params := *DefaultParams
+	params.Seed = 1
+	params.NumPoints = 1500
+	m, err := NewMap(&params)
+	if err != nil {
+		t.Fatalf("NewMap: %v", err)
+	}
+
+	var numLand int
+	for r, e := range m.Elevation() {
+		if e < -1 || e > 1 {
+			t.Errorf("region %d: elevation %f out of range", r, e)
+		}
+		if e >= 0 {
+			numLand++
+		}
+	}
+	if numLand == 0 || numLand == m.NumRegions() {
+		t.Errorf("got %d land regions out of %d", numLand, m.NumRegions())
+	}
+	for r, rain := range m.Rainfall() {
+		if rain < 0 || rain > MaxRainfall {
+			t.Errorf("region %d: rainfall %f out of range", r, rain)
+		}
+	}
+
+	// The same seed results in the same planet.
+	m2, err := NewMap(&params)
+	if err != nil {
+		t.Fatalf("NewMap: %v", err)
+	}
+	for r := range m.Elevation() {
+		if m.Elevation()[r] != m2.Elevation()[r] || m.Rainfall()[r] != m2.Rainfall()[r] {
+			t.Fatalf("region %d differs between maps with the same seed", r)
+		}
+	}
+}
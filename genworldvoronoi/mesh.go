@@ -0,0 +1,127 @@
+package genworldvoronoi
+
+// TriangleMesh is a closed triangle mesh using half-edges (sides), which also
+// represents the dual (voronoi) mesh, where each point is the center of a
+// region and each triangle is a corner of the surrounding regions.
+//
+// The naming follows the original code: s are the sides (half-edges), r the
+// regions (points) and t the triangles.
+//
+// See: https://github.com/redblobgames/dual-mesh
+type TriangleMesh struct {
+	Triangles    []int // region at the start of each side
+	Halfedges    []int // opposite side of each side (-1 if there is none)
+	numSides     int
+	numRegions   int
+	numTriangles int
+	r_in_s       []int // an incoming side for each region
+}
+
+// NewTriangleMesh returns a new triangle mesh with the given number of
+// regions, triangles and half-edges.
+func NewTriangleMesh(numRegions int, triangles, halfedges []int) *TriangleMesh {
+	m := &TriangleMesh{
+		Triangles:    triangles,
+		Halfedges:    halfedges,
+		numSides:     len(triangles),
+		numRegions:   numRegions,
+		numTriangles: len(triangles) / 3,
+		r_in_s:       make([]int, numRegions),
+	}
+
+	// Construct an index for finding the sides connected to a region.
+	for r := range m.r_in_s {
+		m.r_in_s[r] = -1
+	}
+	for s := range triangles {
+		endpoint := triangles[s_next_s(s)]
+		if m.r_in_s[endpoint] == -1 || halfedges[s] == -1 {
+			m.r_in_s[endpoint] = s
+		}
+	}
+	return m
+}
+
+// NumRegions returns the number of regions (points).
+func (m *TriangleMesh) NumRegions() int {
+	return m.numRegions
+}
+
+// NumTriangles returns the number of triangles.
+func (m *TriangleMesh) NumTriangles() int {
+	return m.numTriangles
+}
+
+// s_to_t returns the triangle of the given side.
+func s_to_t(s int) int {
+	return s / 3
+}
+
+// s_prev_s returns the previous side within the triangle.
+func s_prev_s(s int) int {
+	if s%3 == 0 {
+		return s + 2
+	}
+	return s - 1
+}
+
+// s_next_s returns the next side within the triangle.
+func s_next_s(s int) int {
+	if s%3 == 2 {
+		return s - 2
+	}
+	return s + 1
+}
+
+// s_begin_r returns the region at the start of the given side.
+func (m *TriangleMesh) s_begin_r(s int) int {
+	return m.Triangles[s]
+}
+
+// s_end_r returns the region at the end of the given side.
+func (m *TriangleMesh) s_end_r(s int) int {
+	return m.Triangles[s_next_s(s)]
+}
+
+// s_opposite_s returns the opposite side of the given side.
+func (m *TriangleMesh) s_opposite_s(s int) int {
+	return m.Halfedges[s]
+}
+
+// t_circulate_r returns the regions (corners) of the given triangle.
+func (m *TriangleMesh) t_circulate_r(t int) [3]int {
+	return [3]int{m.Triangles[3*t], m.Triangles[3*t+1], m.Triangles[3*t+2]}
+}
+
+// r_circulate_r returns the regions adjacent to the given region.
+// The result is appended to out_r[:0] to avoid allocations.
+func (m *TriangleMesh) r_circulate_r(out_r []int, r int) []int {
+	out_r = out_r[:0]
+	s0 := m.r_in_s[r]
+	incoming := s0
+	for {
+		out_r = append(out_r, m.s_begin_r(incoming))
+		incoming = m.Halfedges[s_next_s(incoming)]
+		if incoming == -1 || incoming == s0 {
+			break
+		}
+	}
+	return out_r
+}
+
+// r_circulate_t returns the triangles surrounding the given region, which are
+// the corners of the voronoi cell of the region.
+// The result is appended to out_t[:0] to avoid allocations.
+func (m *TriangleMesh) r_circulate_t(out_t []int, r int) []int {
+	out_t = out_t[:0]
+	s0 := m.r_in_s[r]
+	incoming := s0
+	for {
+		out_t = append(out_t, s_to_t(incoming))
+		incoming = m.Halfedges[s_next_s(incoming)]
+		if incoming == -1 || incoming == s0 {
+			break
+		}
+	}
+	return out_t
+}
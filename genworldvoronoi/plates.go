@@ -0,0 +1,223 @@
+package genworldvoronoi
+
+import (
+	"math"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// CollisionThreshold is the minimum compression (relative to the plate
+// movement) between two regions of different plates to count as collision.
+var CollisionThreshold = 0.75
+
+// generatePlates picks random regions as plate centers and grows the plates
+// from there using a randomized flood fill. Each plate is assigned a random
+// movement vector and is either oceanic or continental.
+func (m *Map) generatePlates() {
+	mesh := m.mesh
+	m.r_plate = make([]int, mesh.numRegions)
+	for r := range m.r_plate {
+		m.r_plate[r] = -1
+	}
+
+	// Pick random regions as plate centers.
+	m.plate_r = nil
+	for len(m.plate_r) < m.params.NumPlates && len(m.plate_r) < mesh.numRegions {
+		r := m.rand.Intn(mesh.numRegions)
+		if m.r_plate[r] != -1 {
+			continue
+		}
+		m.r_plate[r] = r
+		m.plate_r = append(m.plate_r, r)
+	}
+
+	// Grow the plates using a random search (similar to a breadth first search).
+	queue := append([]int(nil), m.plate_r...)
+	var out_r []int
+	for queueOut := 0; queueOut < len(queue); queueOut++ {
+		pos := queueOut + m.rand.Intn(len(queue)-queueOut)
+		current_r := queue[pos]
+		queue[pos] = queue[queueOut]
+		out_r = mesh.r_circulate_r(out_r, current_r)
+		for _, neighbor_r := range out_r {
+			if m.r_plate[neighbor_r] == -1 {
+				m.r_plate[neighbor_r] = m.r_plate[current_r]
+				queue = append(queue, neighbor_r)
+			}
+		}
+	}
+
+	// Assign a random movement vector to each plate and decide which plates
+	// are oceanic.
+	m.plate_vec = make([]vectors.Vec3, mesh.numRegions)
+	m.plate_ocean = make(map[int]bool)
+	for _, center_r := range m.plate_r {
+		neighbor_r := mesh.r_circulate_r(out_r, center_r)[0]
+		m.plate_vec[center_r] = m.r_xyz[neighbor_r].Sub(m.r_xyz[center_r]).Normalize()
+		if m.rand.Float64() < m.params.OceanPlateChance {
+			m.plate_ocean[center_r] = true
+		}
+	}
+}
+
+// assignDistanceField returns the distance (in hops) of each region to the
+// closest of the seed regions, without crossing any of the stop regions.
+func (m *Map) assignDistanceField(seeds_r []int, stop_r map[int]bool) []float64 {
+	mesh := m.mesh
+	r_distance := make([]float64, mesh.numRegions)
+	for r := range r_distance {
+		r_distance[r] = math.Inf(1)
+	}
+	queue := make([]int, 0, mesh.numRegions)
+	for _, r := range seeds_r {
+		queue = append(queue, r)
+		r_distance[r] = 0
+	}
+
+	// Random search adapted from breadth first search.
+	var out_r []int
+	for queueOut := 0; queueOut < len(queue); queueOut++ {
+		pos := queueOut + m.rand.Intn(len(queue)-queueOut)
+		current_r := queue[pos]
+		queue[pos] = queue[queueOut]
+		out_r = mesh.r_circulate_r(out_r, current_r)
+		for _, neighbor_r := range out_r {
+			if math.IsInf(r_distance[neighbor_r], 1) && !stop_r[neighbor_r] {
+				r_distance[neighbor_r] = r_distance[current_r] + 1
+				queue = append(queue, neighbor_r)
+			}
+		}
+	}
+	return r_distance
+}
+
+// findCollisions returns the regions at plate boundaries that become
+// mountains, coastlines and oceans, depending on the type of the plates and
+// whether the plates move towards each other.
+func (m *Map) findCollisions() (mountain_r, coastline_r, ocean_r []int) {
+	const deltaTime = 1e-2 // simulated movement
+	mesh := m.mesh
+	var out_r []int
+	for current_r := 0; current_r < mesh.numRegions; current_r++ {
+		// Find the adjacent region of another plate that pushes the most into
+		// this region, which is the region getting closest to this one if both
+		// regions move along with their plates.
+		bestCompression := math.Inf(-1)
+		best_r := -1
+		out_r = mesh.r_circulate_r(out_r, current_r)
+		for _, neighbor_r := range out_r {
+			if m.r_plate[current_r] == m.r_plate[neighbor_r] {
+				continue
+			}
+			currentPos := m.r_xyz[current_r]
+			neighborPos := m.r_xyz[neighbor_r]
+			distanceBefore := vectors.Dist3(currentPos, neighborPos)
+			distanceAfter := vectors.Dist3(
+				currentPos.Add(m.plate_vec[m.r_plate[current_r]].Mul(deltaTime)),
+				neighborPos.Add(m.plate_vec[m.r_plate[neighbor_r]].Mul(deltaTime)),
+			)
+			if compression := distanceBefore - distanceAfter; compression > bestCompression {
+				best_r = neighbor_r
+				bestCompression = compression
+			}
+		}
+		if best_r == -1 {
+			continue
+		}
+
+		collided := bestCompression > CollisionThreshold*deltaTime
+		currentOcean := m.plate_ocean[m.r_plate[current_r]]
+		bestOcean := m.plate_ocean[m.r_plate[best_r]]
+		switch {
+		case currentOcean && bestOcean:
+			// Colliding oceanic plates form island arcs.
+			if collided {
+				coastline_r = append(coastline_r, current_r)
+			} else {
+				ocean_r = append(ocean_r, current_r)
+			}
+		case !currentOcean && !bestOcean:
+			// Colliding continental plates form mountain ranges.
+			if collided {
+				mountain_r = append(mountain_r, current_r)
+			}
+		default:
+			// Oceanic plates subduct below continental plates.
+			if collided {
+				mountain_r = append(mountain_r, current_r)
+			} else {
+				coastline_r = append(coastline_r, current_r)
+			}
+		}
+	}
+	return mountain_r, coastline_r, ocean_r
+}
+
+// assignRegionElevation assigns the elevation to each region, which is
+// interpolated between mountains, coastlines and oceans based on the distance
+// to the plate boundaries, with some noise on top (clamped to -1.0 - 1.0).
+func (m *Map) assignRegionElevation() {
+	const epsilon = 1e-3
+	mountain_r, coastline_r, ocean_r := m.findCollisions()
+
+	// The plate centers are either ocean or coastline.
+	for _, r := range m.plate_r {
+		if m.plate_ocean[r] {
+			ocean_r = append(ocean_r, r)
+		} else {
+			coastline_r = append(coastline_r, r)
+		}
+	}
+
+	toSet := func(rs ...[]int) map[int]bool {
+		set := make(map[int]bool)
+		for _, s := range rs {
+			for _, r := range s {
+				set[r] = true
+			}
+		}
+		return set
+	}
+	r_distance_a := m.assignDistanceField(mountain_r, toSet(ocean_r))
+	r_distance_b := m.assignDistanceField(ocean_r, toSet(coastline_r))
+	r_distance_c := m.assignDistanceField(coastline_r, toSet(mountain_r, coastline_r, ocean_r))
+
+	m.r_elevation = make([]float64, m.mesh.numRegions)
+	for r := range m.r_elevation {
+		a := r_distance_a[r] + epsilon
+		b := r_distance_b[r] + epsilon
+		c := r_distance_c[r] + epsilon
+		if math.IsInf(a, 1) && math.IsInf(b, 1) {
+			m.r_elevation[r] = 0.1
+		} else {
+			m.r_elevation[r] = (1/a - 1/b) / (1/a + 1/b + 1/c)
+		}
+		e := m.r_elevation[r] + 0.1*m.fbmNoise(m.r_xyz[r])
+		m.r_elevation[r] = math.Max(-1, math.Min(1, e))
+	}
+}
+
+// assignTriangleElevation assigns the average elevation of the corners to
+// each triangle.
+func (m *Map) assignTriangleElevation() {
+	m.t_elevation = make([]float64, m.mesh.numTriangles)
+	for t := range m.t_elevation {
+		rs := m.mesh.t_circulate_r(t)
+		m.t_elevation[t] = (m.r_elevation[rs[0]] + m.r_elevation[rs[1]] + m.r_elevation[rs[2]]) / 3
+	}
+}
+
+// fbmNoise returns fractal noise (-1.0 - 1.0) for the given point.
+func (m *Map) fbmNoise(p vectors.Vec3) float64 {
+	const octaves = 5
+	const persistence = 2.0 / 3.0
+	var sum, sumOfAmplitudes float64
+	amplitude, frequency := 1.0, 1.0
+	for octave := 0; octave < octaves; octave++ {
+		sum += amplitude * m.noise.Eval3(p.X*frequency, p.Y*frequency, p.Z*frequency)
+		sumOfAmplitudes += amplitude
+		amplitude *= persistence
+		frequency *= 2
+	}
+	return sum / sumOfAmplitudes
+}
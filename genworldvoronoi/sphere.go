@@ -0,0 +1,144 @@
+package genworldvoronoi
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/Flokey82/go_gens/vectors"
+	"github.com/fogleman/delaunay"
+)
+
+// generateFibonacciSphere returns the latitude and longitude (in degrees) of
+// the given number of points distributed evenly on a sphere. The jitter
+// (0.0 - 1.0) randomizes the positions of the points.
+func generateFibonacciSphere(rnd *rand.Rand, numPoints int, jitter float64) [][2]float64 {
+	latLon := make([][2]float64, 0, numPoints)
+	s := 3.6 / math.Sqrt(float64(numPoints))
+	dlong := math.Pi * (3 - math.Sqrt(5)) // ~2.39996323
+	dz := 2.0 / float64(numPoints)
+	long := 0.0
+	z := 1 - dz/2
+	for k := 0; k < numPoints; k++ {
+		r := math.Sqrt(1 - z*z)
+		latDeg := radToDeg(math.Asin(z))
+		lonDeg := radToDeg(long)
+		if jitter > 0 {
+			randLat := rnd.Float64() - rnd.Float64()
+			randLon := rnd.Float64() - rnd.Float64()
+			latDeg += jitter * randLat * (latDeg - radToDeg(math.Asin(math.Max(-1, z-dz*2*math.Pi*r/s))))
+			lonDeg += jitter * randLon * radToDeg(s/r)
+		}
+		latLon = append(latLon, [2]float64{latDeg, math.Mod(lonDeg, 360)})
+		long += dlong
+		z -= dz
+	}
+	return latLon
+}
+
+// latLonToCartesian converts the given latitude and longitude (in degrees)
+// to a point on the unit sphere.
+func latLonToCartesian(latDeg, lonDeg float64) vectors.Vec3 {
+	latRad, lonRad := degToRad(latDeg), degToRad(lonDeg)
+	return vectors.Vec3{
+		X: math.Cos(latRad) * math.Cos(lonRad),
+		Y: math.Cos(latRad) * math.Sin(lonRad),
+		Z: math.Sin(latRad),
+	}
+}
+
+// cartesianToLatLon converts the given point on the unit sphere to latitude
+// and longitude (in degrees, -180 to 180).
+func cartesianToLatLon(p vectors.Vec3) (float64, float64) {
+	return radToDeg(math.Asin(math.Max(-1, math.Min(1, p.Z)))), radToDeg(math.Atan2(p.Y, p.X))
+}
+
+// stereographicProjection projects the points on the unit sphere onto a plane
+// using the north pole (0, 0, 1) as the center of the projection.
+func stereographicProjection(r_xyz []vectors.Vec3) []delaunay.Point {
+	r_XY := make([]delaunay.Point, len(r_xyz))
+	for r, p := range r_xyz {
+		r_XY[r] = delaunay.Point{X: p.X / (1 - p.Z), Y: p.Y / (1 - p.Z)}
+	}
+	return r_XY
+}
+
+// addSouthPoleToMesh closes the hole in the triangulation of the projected
+// points by connecting all unpaired sides (the convex hull) to a new region,
+// which results in a closed mesh.
+// NOTE: The new region is the center of the stereographic projection, which
+// is the north pole, but the name is kept from the original code.
+func addSouthPoleToMesh(southPoleID int, triangles, halfedges []int) ([]int, []int) {
+	numSides := len(triangles)
+	numUnpairedSides := 0
+	firstUnpairedSide := -1
+	pointIDToSideID := make(map[int]int) // seed to side
+	for s := 0; s < numSides; s++ {
+		if halfedges[s] == -1 {
+			numUnpairedSides++
+			pointIDToSideID[triangles[s]] = s
+			firstUnpairedSide = s
+		}
+	}
+
+	newTriangles := make([]int, numSides+3*numUnpairedSides)
+	newHalfedges := make([]int, numSides+3*numUnpairedSides)
+	copy(newTriangles, triangles)
+	copy(newHalfedges, halfedges)
+
+	for i, s := 0, firstUnpairedSide; i < numUnpairedSides; i, s = i+1, pointIDToSideID[newTriangles[s_next_s(s)]] {
+		// Construct a pair for the unpaired side s.
+		newSide := numSides + 3*i
+		newHalfedges[s] = newSide
+		newHalfedges[newSide] = s
+		newTriangles[newSide] = newTriangles[s_next_s(s)]
+
+		// Construct a triangle connecting the new side to the south pole.
+		newTriangles[newSide+1] = newTriangles[s]
+		newTriangles[newSide+2] = southPoleID
+		k := numSides + (3*i+4)%(3*numUnpairedSides)
+		newHalfedges[newSide+2] = k
+		newHalfedges[k] = newSide + 2
+	}
+	return newTriangles, newHalfedges
+}
+
+// makeSphere generates the spherical delaunay mesh for the given number of
+// points and returns the mesh, the position of each region on the unit sphere
+// and the latitude and longitude (in degrees) of each region.
+func makeSphere(rnd *rand.Rand, numPoints int, jitter float64) (*TriangleMesh, []vectors.Vec3, [][2]float64, error) {
+	latLon := generateFibonacciSphere(rnd, numPoints, jitter)
+	r_xyz := make([]vectors.Vec3, 0, len(latLon)+1)
+	for _, ll := range latLon {
+		r_xyz = append(r_xyz, latLonToCartesian(ll[0], ll[1]))
+	}
+
+	tri, err := delaunay.Triangulate(stereographicProjection(r_xyz))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// TODO: Rotate an existing point into this spot instead of creating one.
+	r_xyz = append(r_xyz, vectors.Vec3{X: 0, Y: 0, Z: 1})
+	latLon = append(latLon, [2]float64{90, 0})
+	triangles, halfedges := addSouthPoleToMesh(len(r_xyz)-1, tri.Triangles, tri.Halfedges)
+	return NewTriangleMesh(len(r_xyz), triangles, halfedges), r_xyz, latLon, nil
+}
+
+// generateTriangleCenters returns the centroid of each triangle, which are
+// the corners of the voronoi cells.
+func generateTriangleCenters(mesh *TriangleMesh, r_xyz []vectors.Vec3) []vectors.Vec3 {
+	t_xyz := make([]vectors.Vec3, mesh.numTriangles)
+	for t := range t_xyz {
+		rs := mesh.t_circulate_r(t)
+		t_xyz[t] = r_xyz[rs[0]].Add(r_xyz[rs[1]]).Add(r_xyz[rs[2]]).Mul(1.0 / 3.0)
+	}
+	return t_xyz
+}
+
+func degToRad(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func radToDeg(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
@@ -1,3 +1,5 @@
 module github.com/Flokey82/go_gens
 
 go 1.18
+
+require github.com/ajstarks/svgo v0.0.0-20211024235047-1546f124cd8b
@@ -0,0 +1,68 @@
+package simvillage_tiles
+
+import (
+	"github.com/Flokey82/go_gens/genmap2d"
+)
+
+// GenMap2DTiles maps the genmap2d tile IDs to ground tiles of the default tileset.
+// Tiles not listed here are rendered as grass.
+// TODO: Find proper tiles for water, sand, mountains, etc. in the tileset.
+var GenMap2DTiles = map[byte][]int{
+	genmap2d.TileIDGrass:     grassTiles,
+	genmap2d.TileIDTree:      {218, 243, 243},
+	genmap2d.TileIDShrubland: {244, 243, 243},
+	genmap2d.TileIDRoad:      {245},
+	genmap2d.TileIDBridge:    {245},
+}
+
+// GenMap2DWorld is a chunk source streaming an infinite map generated by
+// genmap2d, where each chunk of the world corresponds to a genmap2d chunk.
+type GenMap2DWorld struct {
+	*genmap2d.ChunkGenerator
+}
+
+// NewGenMap2DWorld returns a new world generated by genmap2d using the given seed.
+func NewGenMap2DWorld(seed int64) *GenMap2DWorld {
+	return &GenMap2DWorld{
+		ChunkGenerator: genmap2d.NewChunkGenerator(seed, screenWidth/tileSize, screenHeight/tileSize),
+	}
+}
+
+// FetchChunk generates the chunk at the given position.
+// TODO: Prevent the player from entering water and mountains.
+func (w *GenMap2DWorld) FetchChunk(x, y int) *MapChunk {
+	m := w.Chunk(x, y)
+	chunk := newMapChunk(m.Width, m.Height)
+	r := newRandForChunk(x, y)
+	for i, t := range m.Cells {
+		tiles, ok := GenMap2DTiles[t]
+		if !ok {
+			tiles = grassTiles
+		}
+		chunk.Ground.Tiles[i] = tiles[r.Intn(len(tiles))]
+	}
+
+	// Draw a house for each village, moved so that it fits into the chunk.
+	for _, v := range m.Villages {
+		dx := clamp(v.X-house1.Width/2, 0, m.Width-house1.Width)
+		dy := clamp(v.Y-house1.Height/2, 0, m.Height-house1.Height)
+		chunk.drawObject(house1, dx, dy)
+	}
+	return chunk
+}
+
+// TileSet returns the tileset used for this world.
+func (w *GenMap2DWorld) TileSet() *TileSet {
+	return tilesDefaultSet
+}
+
+// clamp returns v clamped to the range [lo, hi].
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,47 @@
+package utils
+
+// XorshiftSource is a small xorshift64* rand.Source. Unlike the sources of
+// math/rand, its complete internal state is a single exported value, so the
+// exact RNG state can be stored (e.g. in a savegame) and restored later by
+// simply assigning it.
+//
+// See: https://en.wikipedia.org/wiki/Xorshift#xorshift*
+type XorshiftSource struct {
+	State uint64 // internal state (must not be zero)
+}
+
+// NewXorshiftSource returns a new xorshift source seeded with the given seed.
+func NewXorshiftSource(seed int64) *XorshiftSource {
+	s := &XorshiftSource{}
+	s.Seed(seed)
+	return s
+}
+
+// Seed initializes the state from the given seed. The seed is scrambled
+// using splitmix64, so that similar seeds result in unrelated sequences and
+// the state is never zero.
+func (s *XorshiftSource) Seed(seed int64) {
+	z := uint64(seed) + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	if z == 0 {
+		z = 0x9e3779b97f4a7c15
+	}
+	s.State = z
+}
+
+// Uint64 returns a pseudo-random 64-bit integer.
+func (s *XorshiftSource) Uint64() uint64 {
+	x := s.State
+	x ^= x >> 12
+	x ^= x << 25
+	x ^= x >> 27
+	s.State = x
+	return x * 0x2545f4914f6cdd1d
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer.
+func (s *XorshiftSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}